@@ -12,7 +12,7 @@ import (
 
 var fileHeadBytes int64 = 32
 
-func listAllFiles(rootPath string) []string {
+func listAllFiles(rootPath string, cache pkg.CacheConfig) []string {
 	var result []string
 
 	err := filepath.Walk(rootPath, func(file string, info os.FileInfo, err error) error {
@@ -21,6 +21,10 @@ func listAllFiles(rootPath string) []string {
 			return err
 		}
 
+		if info.IsDir() && file != rootPath && cache.IsIgnoredDir(file) {
+			return filepath.SkipDir
+		}
+
 		if !info.IsDir() {
 			result = append(result, file)
 		}
@@ -76,13 +80,13 @@ func fileReadHeadBytes(file string, n int64) []byte {
 	return headBytes[:m]
 }
 
-func processFiles(folderOrFiles []string, template string) error {
+func readDocuments(folderOrFiles []string, cache pkg.CacheConfig) ([]pkg.ParsedDocument, error) {
 
 	var files []string
 	for _, folderOrFile := range folderOrFiles {
 		log.Infof("collecting files from '%s'", folderOrFile)
 
-		for _, file := range append(files, listAllFiles(folderOrFile)...) {
+		for _, file := range append(files, listAllFiles(folderOrFile, cache)...) {
 
 			fileInfo, err := os.Stat(file)
 			if err != nil {
@@ -93,9 +97,10 @@ func processFiles(folderOrFiles []string, template string) error {
 			if fileInfo.IsDir() {
 				continue
 			}
-			if fileInfo.Size() < fileHeadBytes {
+			if fileInfo.Size() == 0 {
 				continue
 			}
+			// files smaller than fileHeadBytes are detected by their whole content
 			headBytes := fileReadHeadBytes(path.Join(file), fileHeadBytes)
 
 			if isText(headBytes) {
@@ -112,18 +117,27 @@ func processFiles(folderOrFiles []string, template string) error {
 	for _, file := range files {
 		content, err := os.ReadFile(file)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		document, err := pkg.ParseDocument(pkg.Document{File: file, Content: string(content)})
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		documents = append(documents, document)
 	}
 
-	errors := pkg.ValidateDocuments(documents)
+	return documents, nil
+}
+
+func processFiles(folderOrFiles []string, template string, config pkg.Config) error {
+	documents, err := readDocuments(folderOrFiles, config.Cache)
+	if err != nil {
+		return err
+	}
+
+	errors := pkg.ValidateDocumentsWithConfig(documents, config)
 
 	if len(errors) > 0 {
 		for _, err := range errors {
@@ -143,14 +157,14 @@ func processFiles(folderOrFiles []string, template string) error {
 			log.Infof("found %d snippets in '%s'", snippetCount, document.File)
 
 			for _, line := range document.Lines {
-				if line.Snippet != nil && line.Snippet.IsSnippet && line.Snippet.IsStart {
+				if line.Snippet != nil && line.Snippet.Kind == pkg.KindSnippet && line.Snippet.IsStart {
 					log.Infof("\t%s", line.Snippet.Id)
 				}
 			}
 		}
 	}
 
-	replacedDocuments, err := pkg.ReplaceSnippets(documents, template)
+	replacedDocuments, err := pkg.ReplaceSnippetsWithConfig(documents, template, config)
 	if err != nil {
 		return err
 	}
@@ -177,6 +191,42 @@ func processFiles(folderOrFiles []string, template string) error {
 	return nil
 }
 
+func testSnippets(folderOrFiles []string, config pkg.Config) error {
+	documents, err := readDocuments(folderOrFiles, config.Cache)
+	if err != nil {
+		return err
+	}
+
+	errors := pkg.ValidateDocumentsWithConfig(documents, config)
+	if len(errors) > 0 {
+		for _, err := range errors {
+			log.Error(err)
+		}
+		return fmt.Errorf("validating snippets failed")
+	}
+
+	failed := 0
+	for _, check := range pkg.CheckSnippets(documents, config) {
+		switch {
+		case check.Skipped:
+			log.Infof("skipped snippet '%s' at '%s:%d'", check.Id, check.File, check.Line)
+		case check.Err != nil:
+			failed++
+			log.Errorf("snippet '%s' at '%s:%d' failed: %s", check.Id, check.File, check.Line, check.Err)
+		default:
+			log.Infof("snippet '%s' at '%s:%d' passed", check.Id, check.File, check.Line)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d snippet(s) failed", failed)
+	}
+
+	log.Info("snippets successfully tested")
+
+	return nil
+}
+
 func fileOrDirExists(filename string) bool {
 	_, err := os.Stat(filename)
 	return !os.IsNotExist(err)
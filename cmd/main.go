@@ -9,6 +9,66 @@ import (
 	"strings"
 )
 
+var configFlag = &cli.StringFlag{
+	Name:  "config",
+	Usage: fmt.Sprintf("config file to use, defaults to '%s' if it exists", pkg.DefaultConfigFile),
+}
+
+var varFlag = &cli.StringSliceFlag{
+	Name:  "var",
+	Usage: fmt.Sprintf("set a variable like 'port=8080', overrides variables from the config file and from '%s*' environment variables", pkg.VarEnvPrefix),
+}
+
+var profileFlag = &cli.StringSliceFlag{
+	Name:  "profile",
+	Usage: "activate profiles like 'enterprise', overrides the active profiles from the config file",
+}
+
+func loadConfig(context *cli.Context) (*pkg.Config, error) {
+	config := &pkg.Config{}
+
+	var err error
+	if context.IsSet("config") {
+		config, err = pkg.LoadConfig(context.String("config"))
+	} else if fileOrDirExists(pkg.DefaultConfigFile) {
+		log.Infof("using config file '%s'", pkg.DefaultConfigFile)
+		config, err = pkg.LoadConfig(pkg.DefaultConfigFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(config.Cache.Dir) == 0 {
+		config.Cache.Dir = pkg.DefaultCacheDir
+	}
+	config.Cache.Refresh = context.Bool("no-cache")
+
+	if context.IsSet("profile") {
+		config.Profiles.Active = context.StringSlice("profile")
+	}
+
+	if config.Vars == nil {
+		config.Vars = map[string]string{}
+	}
+	for name, value := range pkg.EnvVars(os.Environ()) {
+		config.Vars[name] = value
+	}
+	for _, definition := range context.StringSlice("var") {
+		name, value, err := pkg.ParseVar(definition)
+		if err != nil {
+			return nil, err
+		}
+		config.Vars[name] = value
+	}
+
+	// profiles and variables from the command line and the environment need the same checks as the config file
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
 func main() {
 	log.SetReportTimestamp(false)
 
@@ -18,16 +78,66 @@ func main() {
 		DefaultCommand: "replace",
 		Commands: []*cli.Command{
 			{
-				Name:  "show-templates",
-				Usage: "show default templates for snippet replacements",
+				Name:      "show-templates",
+				Usage:     "show default templates for snippet replacements",
+				ArgsUsage: "[files...]",
+				Flags: []cli.Flag{
+					configFlag,
+				},
 				Action: func(cCtx *cli.Context) error {
-					for _, template := range pkg.DefaultSnippetTemplates {
-						log.Infof("template for file extension(s) %s: '%s'", strings.Join(template.Extensions, ", "), strings.ReplaceAll(template.Template, "\n", "\\n"))
+					config, err := loadConfig(cCtx)
+					if err != nil {
+						return cli.Exit(fmt.Sprintf("loading the config failed: %s", err), 6)
+					}
+
+					if cCtx.NArg() > 0 {
+						for _, file := range cCtx.Args().Slice() {
+							template := pkg.FindTemplate(file, config.SnippetTemplates())
+							if template == nil {
+								log.Infof("no template found for '%s', snippets will be inserted as-is", file)
+							} else {
+								log.Infof("%s template will be used for '%s': '%s'", template.Name, file, strings.ReplaceAll(template.Template, "\n", "\\n"))
+							}
+						}
+
+						return cli.Exit("", 4)
+					}
+
+					for _, template := range config.SnippetTemplates() {
+						log.Infof("%s template for file pattern(s) %s (priority %d): '%s'", template.Name, strings.Join(template.Patterns, ", "), template.Priority, strings.ReplaceAll(template.Template, "\n", "\\n"))
 					}
 
 					return cli.Exit("", 4)
 				},
 			},
+			{
+				Name:      "test",
+				Usage:     "check all snippets used by insertSnippet markers with the harness for their language",
+				ArgsUsage: "[source folders or files...]",
+				Flags: []cli.Flag{
+					configFlag,
+					varFlag,
+					profileFlag,
+				},
+				Action: func(context *cli.Context) error {
+					if context.NArg() == 0 {
+						return cli.Exit("no source folders provided", 3)
+					}
+
+					for _, folderOrFile := range context.Args().Slice() {
+						if !fileOrDirExists(folderOrFile) {
+							return cli.Exit(fmt.Sprintf("folder or file '%s' not found", folderOrFile), 5)
+						}
+					}
+
+					config, err := loadConfig(context)
+					if err != nil {
+						return cli.Exit(fmt.Sprintf("loading the config failed: %s", err), 6)
+					}
+
+					return testSnippets(context.Args().Slice(), *config)
+				},
+			},
 			{
 				Name:      "replace",
 				Usage:     "replace snippets in all source folders and files",
@@ -37,6 +147,13 @@ func main() {
 						Name:  "template",
 						Usage: fmt.Sprintf("set custom snippet template to use for replacements, available variables are:\n%s", pkg.TemplateHelp),
 					},
+					configFlag,
+					varFlag,
+					profileFlag,
+					&cli.BoolFlag{
+						Name:  "no-cache",
+						Usage: "run all commands and programs again instead of using their cached output",
+					},
 				},
 				Action: func(context *cli.Context) error {
 					if context.IsSet("template") {
@@ -56,7 +173,12 @@ func main() {
 						}
 					}
 
-					return processFiles(context.Args().Slice(), context.String("template"))
+					config, err := loadConfig(context)
+					if err != nil {
+						return cli.Exit(fmt.Sprintf("loading the config failed: %s", err), 6)
+					}
+
+					return processFiles(context.Args().Slice(), context.String("template"), *config)
 				},
 			},
 		},
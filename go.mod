@@ -4,14 +4,15 @@ go 1.19
 
 require (
 	github.com/alecthomas/assert/v2 v2.3.0
+	github.com/charmbracelet/log v0.3.1
 	github.com/urfave/cli/v2 v2.27.1
+	golang.org/x/mod v0.13.0
 )
 
 require (
 	github.com/alecthomas/repr v0.2.0 // indirect
 	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
 	github.com/charmbracelet/lipgloss v0.9.1 // indirect
-	github.com/charmbracelet/log v0.3.1 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
 	github.com/go-logfmt/logfmt v0.6.0 // indirect
 	github.com/hexops/gotextdiff v1.0.3 // indirect
@@ -0,0 +1,345 @@
+package pkg
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// blockLanguage describes the lexical structure of a language, just enough to skip strings and comments and to
+// find the end of a block
+type blockLanguage struct {
+	lineComment string
+	// stringQuotes are the characters starting a string that may contain escapes
+	stringQuotes string
+	// charLiterals is set if ' starts a character literal like 'a' (or a lifetime like 'a in Rust) instead of a string
+	charLiterals bool
+	// tripleQuotes is set for languages supporting """ and ''' strings
+	tripleQuotes bool
+	// indentation is set for languages where the block structure is defined by indentation instead of braces
+	indentation bool
+}
+
+var cLikeBlockLanguage = blockLanguage{lineComment: "//", stringQuotes: `"`, charLiterals: true}
+var jsLikeBlockLanguage = blockLanguage{lineComment: "//", stringQuotes: "\"'`"}
+var pythonBlockLanguage = blockLanguage{lineComment: "#", stringQuotes: `"'`, tripleQuotes: true, indentation: true}
+
+var blockLanguages = map[string]blockLanguage{
+	"c":     cLikeBlockLanguage,
+	"cc":    cLikeBlockLanguage,
+	"cpp":   cLikeBlockLanguage,
+	"cs":    cLikeBlockLanguage,
+	"dart":  cLikeBlockLanguage,
+	"go":    cLikeBlockLanguage,
+	"h":     cLikeBlockLanguage,
+	"hpp":   cLikeBlockLanguage,
+	"java":  cLikeBlockLanguage,
+	"kt":    cLikeBlockLanguage,
+	"php":   cLikeBlockLanguage,
+	"rs":    cLikeBlockLanguage,
+	"scala": cLikeBlockLanguage,
+	"swift": cLikeBlockLanguage,
+	"js":    jsLikeBlockLanguage,
+	"jsx":   jsLikeBlockLanguage,
+	"mjs":   jsLikeBlockLanguage,
+	"ts":    jsLikeBlockLanguage,
+	"tsx":   jsLikeBlockLanguage,
+	"py":    pythonBlockLanguage,
+}
+
+// declarationKeywordExpression matches keywords that may precede the name of a declaration, e.g. 'class Name'
+var declarationKeywordExpression = regexp.MustCompile(`(^|\s)(class|interface|enum|struct|trait|impl|fn|function|def|record|type|union|namespace|mod|module|object)$`)
+
+// variableKeywordExpression and arrowFunctionExpression match function expressions like 'const name = (a) => {'
+var variableKeywordExpression = regexp.MustCompile(`(^|\s)(const|let|var)$`)
+var arrowFunctionExpression = regexp.MustCompile(`^(:[^=]*)?=\s*(async\s+)?(function\b|\([^)]*\)\s*(:[^=]*)?=>|[A-Za-z0-9_$]+\s*=>)`)
+
+var callExpression = regexp.MustCompile(`^(<[^>]*>\s*)?\(`)
+
+// controlKeywords may precede a call, so 'return name(...)' is not mistaken for a declaration
+var controlKeywords = map[string]bool{"return": true, "new": true, "else": true, "await": true, "yield": true, "throw": true, "case": true, "in": true, "not": true, "and": true, "or": true}
+
+var blockIdentifierExpression = regexp.MustCompile(`[A-Za-z0-9_$]+$`)
+
+// codeMask returns for every byte of source whether it is code, as opposed to being part of a string or comment
+func codeMask(source string, language blockLanguage) []bool {
+	mask := make([]bool, len(source))
+
+	for i := 0; i < len(source); {
+		switch {
+		case strings.HasPrefix(source[i:], language.lineComment):
+			end := strings.Index(source[i:], "\n")
+			if end < 0 {
+				end = len(source) - i
+			}
+			i += end
+
+		case !language.indentation && strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				i = len(source)
+			} else {
+				i += end + 4
+			}
+
+		case language.tripleQuotes && (strings.HasPrefix(source[i:], `"""`) || strings.HasPrefix(source[i:], `'''`)):
+			end := strings.Index(source[i+3:], source[i:i+3])
+			if end < 0 {
+				i = len(source)
+			} else {
+				i += end + 6
+			}
+
+		case strings.ContainsRune(language.stringQuotes, rune(source[i])):
+			i = skipQuoted(source, i)
+
+		case language.charLiterals && source[i] == '\'':
+			if length := charLiteralLength(source[i:]); length > 0 {
+				i += length
+			} else {
+				mask[i] = true
+				i++
+			}
+
+		default:
+			mask[i] = true
+			i++
+		}
+	}
+
+	return mask
+}
+
+// skipQuoted returns the index after the string starting at start, honouring backslash escapes
+func skipQuoted(source string, start int) int {
+	quote := source[start]
+	for i := start + 1; i < len(source); i++ {
+		switch source[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+
+	return len(source)
+}
+
+// charLiteralLength returns the length of a character literal like 'a', '\n' or '\u{1F600}' at the beginning of
+// source, or 0 if source does not start with a character literal, e.g. for Rust lifetimes like 'a
+func charLiteralLength(source string) int {
+	if len(source) >= 3 && source[1] != '\\' && source[1] != '\'' {
+		if end := strings.IndexByte(source[1:], '\''); end > 0 && end <= 4 && !strings.ContainsAny(source[1:end+1], " \n") {
+			return end + 2
+		}
+		return 0
+	}
+
+	if len(source) >= 4 && source[1] == '\\' {
+		if end := strings.IndexByte(source[3:], '\''); end >= 0 && end <= 10 && !strings.Contains(source[2:end+3], "\n") {
+			return end + 4
+		}
+	}
+
+	return 0
+}
+
+// maskedSource returns source with all strings and comments replaced by spaces, newlines are preserved
+func maskedSource(source string, mask []bool) string {
+	masked := []byte(source)
+	for i := range masked {
+		if !mask[i] && masked[i] != '\n' {
+			masked[i] = ' '
+		}
+	}
+
+	return string(masked)
+}
+
+func lineStartOffset(source string, offset int) int {
+	return strings.LastIndex(source[:offset], "\n") + 1
+}
+
+func lineEndOffset(source string, offset int) int {
+	end := strings.Index(source[offset:], "\n")
+	if end < 0 {
+		return len(source)
+	}
+
+	return offset + end
+}
+
+// isDeclaration reports whether the name found at offset inside of the masked source looks like a declaration
+func isDeclaration(masked string, offset int, name string) bool {
+	prefix := strings.TrimSpace(masked[lineStartOffset(masked, offset):offset])
+	suffix := strings.TrimLeft(masked[offset+len(name):], " \t")
+
+	if declarationKeywordExpression.MatchString(prefix) {
+		return true
+	}
+
+	if variableKeywordExpression.MatchString(prefix) {
+		return arrowFunctionExpression.MatchString(suffix)
+	}
+
+	if !callExpression.MatchString(suffix) {
+		return false
+	}
+
+	if len(prefix) == 0 {
+		return true
+	}
+
+	identifier := blockIdentifierExpression.FindString(prefix)
+	if len(identifier) > 0 {
+		return !controlKeywords[identifier]
+	}
+
+	return strings.HasSuffix(prefix, ">") || strings.HasSuffix(prefix, "]") || strings.HasSuffix(prefix, "*") || strings.HasSuffix(prefix, "&")
+}
+
+// findBlock returns the start and end offset of the block declaring name within source[start:end]
+func findBlock(source string, masked string, language blockLanguage, name string, start int, end int) (int, int, error) {
+	nameExpression := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+
+	for _, match := range nameExpression.FindAllStringIndex(masked[start:end], -1) {
+		offset := start + match[0]
+		if !isDeclaration(masked, offset, name) {
+			continue
+		}
+
+		var blockEnd int
+		if language.indentation {
+			blockEnd = findIndentationBlockEnd(source, masked, offset, end)
+		} else {
+			blockEnd = findBraceBlockEnd(masked, offset, end)
+		}
+
+		if blockEnd < 0 {
+			continue
+		}
+
+		return includeAnnotations(source, lineStartOffset(source, offset)), lineEndOffset(source, blockEnd), nil
+	}
+
+	return 0, 0, fmt.Errorf("block '%s' not found", name)
+}
+
+// findBraceBlockEnd returns the offset of the brace closing the first block after offset, or -1 if a ';' ends the
+// declaration before a block is opened
+func findBraceBlockEnd(masked string, offset int, end int) int {
+	depth := 0
+	parentheses := 0
+
+	for i := offset; i < end; i++ {
+		switch masked[i] {
+		case '(':
+			parentheses++
+		case ')':
+			parentheses--
+		case ';':
+			if depth == 0 && parentheses == 0 {
+				return -1
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// findIndentationBlockEnd returns the offset of the last line belonging to the indented block declared at offset
+func findIndentationBlockEnd(source string, masked string, offset int, end int) int {
+	lineStart := lineStartOffset(masked, offset)
+	declarationIndentation := len(masked[lineStart:]) - len(strings.TrimLeft(masked[lineStart:], " \t"))
+
+	header := offset
+	parentheses := 0
+	for ; header < end; header++ {
+		if strings.ContainsRune("([{", rune(masked[header])) {
+			parentheses++
+		} else if strings.ContainsRune(")]}", rune(masked[header])) {
+			parentheses--
+		} else if masked[header] == ':' && parentheses == 0 {
+			break
+		}
+	}
+
+	if header >= end {
+		return -1
+	}
+
+	blockEnd := header
+	for lineStart = lineEndOffset(masked, header) + 1; lineStart < end; lineStart = lineEndOffset(masked, lineStart) + 1 {
+		line := source[lineStart:lineEndOffset(source, lineStart)]
+		if isBlank(line) {
+			continue
+		}
+
+		indentation := len(line) - len(strings.TrimLeft(line, " \t"))
+		firstCharacter := lineStart + indentation
+
+		if !isBlank(masked[firstCharacter:lineEndOffset(masked, firstCharacter)]) && indentation <= declarationIndentation {
+			break
+		}
+
+		if !isBlank(masked[lineStart:lineEndOffset(masked, lineStart)]) || source[firstCharacter] != '#' {
+			blockEnd = firstCharacter
+		}
+	}
+
+	return blockEnd
+}
+
+// includeAnnotations moves start to include directly preceding annotation, decorator or attribute lines like
+// '@Override', '@property' or '#[derive(Debug)]'
+func includeAnnotations(source string, start int) int {
+	for start > 0 {
+		previousStart := lineStartOffset(source, start-1)
+		previous := strings.TrimSpace(source[previousStart : start-1])
+		if !strings.HasPrefix(previous, "@") && !strings.HasPrefix(previous, "#[") {
+			break
+		}
+		start = previousStart
+	}
+
+	return start
+}
+
+// extractBlock returns the block of a function, class or method referenced like 'path/to/file.java#Class.method'
+func extractBlock(documents []ParsedDocument, marker *SnippetMarker) ([]string, string, error) {
+	file, name := splitFileId(marker.Id)
+	if len(name) == 0 {
+		return nil, "", fmt.Errorf("no block name specified, expected format is 'file#name'")
+	}
+
+	language, ok := blockLanguages[strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")]
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported file type '%s'", filepath.Ext(file))
+	}
+
+	if getDocumentForFile(documents, file) == nil {
+		return nil, "", fmt.Errorf("file '%s' not found", file)
+	}
+
+	source := strings.Join(getContentForFile(documents, file), "\n")
+	masked := maskedSource(source, codeMask(source, language))
+
+	start, end := 0, len(source)
+	for _, part := range strings.Split(name, ".") {
+		var err error
+		start, end, err = findBlock(source, masked, language, part, start, end)
+		if err != nil {
+			return nil, "", fmt.Errorf("block '%s' not found", name)
+		}
+	}
+
+	return strings.Split(source[start:end], "\n"), file, nil
+}
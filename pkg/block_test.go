@@ -0,0 +1,213 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+func extractBlockTest(t *testing.T, file string, source string, marker string) []string {
+	document, err := ParseDocument(Document{File: file, Content: source})
+	assert.NoError(t, err)
+
+	lines, _, err := extractBlock([]ParsedDocument{document}, ParseMarker(marker))
+	assert.NoError(t, err)
+
+	return lines
+}
+
+var javaBlockSource = `package example;
+
+public class Greeter {
+
+    private static final String GREETING = "public void greet() {";
+
+    /* greet() { */
+    @Override
+    public void greet(String name) {
+        if (name == null) {
+            throw new IllegalArgumentException("}");
+        }
+        char c = '}';
+        System.out.println(greeting(name));
+    }
+
+    private String greeting(String name) {
+        return "Hello " + name; // }
+    }
+}
+`
+
+func TestExtractBlockJavaMethod(t *testing.T) {
+	assert.Equal(t, []string{
+		"    @Override",
+		"    public void greet(String name) {",
+		"        if (name == null) {",
+		"            throw new IllegalArgumentException(\"}\");",
+		"        }",
+		"        char c = '}';",
+		"        System.out.println(greeting(name));",
+		"    }",
+	}, extractBlockTest(t, "src/Greeter.java", javaBlockSource, "insertBlock[Greeter.java#greet]"))
+}
+
+func TestExtractBlockJavaNested(t *testing.T) {
+	assert.Equal(t, []string{
+		"    private String greeting(String name) {",
+		"        return \"Hello \" + name; // }",
+		"    }",
+	}, extractBlockTest(t, "src/Greeter.java", javaBlockSource, "insertBlock[Greeter.java#Greeter.greeting]"))
+}
+
+func TestExtractBlockJavaClass(t *testing.T) {
+	lines := extractBlockTest(t, "src/Greeter.java", javaBlockSource, "insertBlock[Greeter.java#Greeter]")
+	assert.Equal(t, "public class Greeter {", lines[0])
+	assert.Equal(t, "}", lines[len(lines)-1])
+}
+
+var typeScriptBlockSource = "import { x } from './x';\n" +
+	"\n" +
+	"const template = `function render() {`;\n" +
+	"\n" +
+	"export function render(name: string): string {\n" +
+	"  return `<div>${name}}</div>`;\n" +
+	"}\n" +
+	"\n" +
+	"export const handler = async (event: Event): Promise<void> => {\n" +
+	"  await render('}');\n" +
+	"};\n"
+
+func TestExtractBlockTypeScriptFunction(t *testing.T) {
+	assert.Equal(t, []string{
+		"export function render(name: string): string {",
+		"  return `<div>${name}}</div>`;",
+		"}",
+	}, extractBlockTest(t, "src/render.ts", typeScriptBlockSource, "insertBlock[render.ts#render]"))
+}
+
+func TestExtractBlockTypeScriptArrowFunction(t *testing.T) {
+	assert.Equal(t, []string{
+		"export const handler = async (event: Event): Promise<void> => {",
+		"  await render('}');",
+		"};",
+	}, extractBlockTest(t, "src/render.ts", typeScriptBlockSource, "insertBlock[render.ts#handler]"))
+}
+
+var cBlockSource = `#include <stdio.h>
+
+int add(int a, int b);
+
+/* int add(int a, int b) { */
+int add(int a, int b) {
+    return a + b;
+}
+
+int main(void) {
+    printf("%d\n", add(1, 2));
+    return 0;
+}
+`
+
+func TestExtractBlockC(t *testing.T) {
+	assert.Equal(t, []string{"int add(int a, int b) {", "    return a + b;", "}"}, extractBlockTest(t, "main.c", cBlockSource, "insertBlock[main.c#add]"))
+}
+
+var rustBlockSource = `struct Parser<'a> {
+    input: &'a str,
+}
+
+impl<'a> Parser<'a> {
+    #[inline]
+    fn parse(&self) -> Option<char> {
+        let brace = '{';
+        self.input.chars().find(|c| *c != brace)
+    }
+}
+`
+
+func TestExtractBlockRust(t *testing.T) {
+	assert.Equal(t, []string{
+		"    #[inline]",
+		"    fn parse(&self) -> Option<char> {",
+		"        let brace = '{';",
+		"        self.input.chars().find(|c| *c != brace)",
+		"    }",
+	}, extractBlockTest(t, "src/parser.rs", rustBlockSource, "insertBlock[parser.rs#parse]"))
+}
+
+func TestExtractBlockRustStruct(t *testing.T) {
+	assert.Equal(t, []string{"struct Parser<'a> {", "    input: &'a str,", "}"}, extractBlockTest(t, "src/parser.rs", rustBlockSource, "insertBlock[parser.rs#Parser]"))
+}
+
+var pythonBlockSource = `import os
+
+
+class Greeter:
+    """Greets people.
+
+def not_a_function():
+    """
+
+    @staticmethod
+    def greet(name):
+        message = f"Hello {name}"
+        # print it
+
+        print(message)
+
+    def other(self):
+        pass
+# trailing comment
+
+
+def main():
+    Greeter.greet("world")
+`
+
+func TestExtractBlockPythonMethod(t *testing.T) {
+	assert.Equal(t, []string{
+		"    @staticmethod",
+		"    def greet(name):",
+		"        message = f\"Hello {name}\"",
+		"        # print it",
+		"",
+		"        print(message)",
+	}, extractBlockTest(t, "greeter.py", pythonBlockSource, "insertBlock[greeter.py#Greeter.greet]"))
+}
+
+func TestExtractBlockPythonClass(t *testing.T) {
+	lines := extractBlockTest(t, "greeter.py", pythonBlockSource, "insertBlock[greeter.py#Greeter]")
+	assert.Equal(t, "class Greeter:", lines[0])
+	assert.Equal(t, "        pass", lines[len(lines)-1])
+}
+
+func TestExtractBlockPythonFunction(t *testing.T) {
+	assert.Equal(t, []string{"def main():", "    Greeter.greet(\"world\")"}, extractBlockTest(t, "greeter.py", pythonBlockSource, "insertBlock[greeter.py#main]"))
+}
+
+func TestExtractBlockErrors(t *testing.T) {
+	document1, err := ParseDocument(Document{File: "greeter.py", Content: pythonBlockSource})
+	assert.NoError(t, err)
+	document2, err := ParseDocument(Document{File: "notes.txt", Content: "lorem ipsum"})
+	assert.NoError(t, err)
+	documents := []ParsedDocument{document1, document2}
+
+	_, _, err = extractBlock(documents, ParseMarker("insertBlock[greeter.py#not_a_function]"))
+	assert.EqualError(t, err, "block 'not_a_function' not found")
+
+	_, _, err = extractBlock(documents, ParseMarker("insertBlock[greeter.py#Greeter.main]"))
+	assert.EqualError(t, err, "block 'Greeter.main' not found")
+
+	_, _, err = extractBlock(documents, ParseMarker("insertBlock[notes.txt#lorem]"))
+	assert.EqualError(t, err, "unsupported file type '.txt'")
+
+	_, _, err = extractBlock(documents, ParseMarker("insertBlock[greeter.py]"))
+	assert.EqualError(t, err, "no block name specified, expected format is 'file#name'")
+}
+
+func TestCharLiteralLength(t *testing.T) {
+	assert.Equal(t, 3, charLiteralLength("'a'"))
+	assert.Equal(t, 4, charLiteralLength("'\\n'"))
+	assert.Equal(t, 11, charLiteralLength("'\\u{1F600}'"))
+	assert.Equal(t, 0, charLiteralLength("'a str"))
+	assert.Equal(t, 0, charLiteralLength("'a>"))
+}
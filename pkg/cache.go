@@ -0,0 +1,176 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultCacheDir is the directory that caches the output of commands and programs
+const DefaultCacheDir = ".snex/cache"
+
+// CacheConfig configures the cache for the output of 'insertCommand' and 'insertOutput', caching is disabled if
+// Dir is empty
+type CacheConfig struct {
+	Dir string `json:"dir"`
+	// Refresh ignores cached output, the output is still stored in the cache
+	Refresh bool `json:"-"`
+}
+
+// cacheKey identifies the output of a command, the output is only reused if all fields are unchanged
+type cacheKey struct {
+	Args       []string          `json:"args"`
+	Dir        string            `json:"dir"`
+	Env        []string          `json:"env"`
+	Attributes map[string]string `json:"attributes"`
+	Inputs     map[string]string `json:"inputs"`
+}
+
+func (key cacheKey) hash() (string, error) {
+	content, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// parseInputPatterns splits the comma separated 'inputs' attribute
+func parseInputPatterns(inputs string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(inputs, ",") {
+		if pattern = strings.TrimSpace(pattern); len(pattern) > 0 {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	return patterns
+}
+
+// hashInputs returns the content hashes of all files below dir matching one of the patterns, keyed by their path
+// relative to dir. Every pattern has to match at least one file, directories ignored by the cache are not searched.
+func hashInputs(dir string, patterns []string, cache CacheConfig) (map[string]string, error) {
+	hashes := map[string]string{}
+	matched := map[string]bool{}
+
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if file != dir && cache.IsIgnoredDir(file) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relative, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		relative = filepath.ToSlash(relative)
+
+		isInput := false
+		for _, pattern := range patterns {
+			if matchPattern(pattern, relative) {
+				matched[pattern] = true
+				isInput = true
+			}
+		}
+
+		if !isInput {
+			return nil
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		hash := sha256.Sum256(content)
+		hashes[relative] = hex.EncodeToString(hash[:])
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range patterns {
+		if !matched[pattern] {
+			return nil, fmt.Errorf("input '%s' does not match any files in '%s'", pattern, dir)
+		}
+	}
+
+	return hashes, nil
+}
+
+// cachedCommand returns the cached output for the command if its key is unchanged, or runs the command and stores
+// its output in the cache. Without input patterns the output is never cached, because there is nothing that tells
+// when it changes.
+func cachedCommand(cache CacheConfig, args []string, dir string, env []string, marker *SnippetMarker, inputs []string, run func() ([]string, error)) ([]string, error) {
+	if len(cache.Dir) == 0 || len(inputs) == 0 {
+		return run()
+	}
+
+	hashes, err := hashInputs(dir, inputs, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	absoluteDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sortedEnv := append([]string{}, env...)
+	sort.Strings(sortedEnv)
+
+	key, err := cacheKey{Args: args, Dir: absoluteDir, Env: sortedEnv, Attributes: marker.Attributes, Inputs: hashes}.hash()
+	if err != nil {
+		return nil, err
+	}
+
+	file := filepath.Join(cache.Dir, key)
+
+	if !cache.Refresh {
+		content, err := os.ReadFile(file)
+		if err == nil {
+			var lines []string
+			if err := json.Unmarshal(content, &lines); err == nil {
+				return lines, nil
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	lines, err := run()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := json.Marshal(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(cache.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(file, content, 0o644); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
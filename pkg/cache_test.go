@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseInputPatterns(t *testing.T) {
+	assert.Equal(t, []string{"Makefile", "src/**/*.go"}, parseInputPatterns(" Makefile, src/**/*.go,"))
+	assert.Equal(t, 0, len(parseInputPatterns("")))
+}
+
+func TestHashInputs(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "Makefile"), []byte("help:"), 0o600))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, ".snex", "cache"), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".snex", "cache", "Makefile"), []byte("ignored"), 0o600))
+
+	hashes, err := hashInputs(dir, []string{"Makefile"}, CacheConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(hashes))
+	assert.NotZero(t, hashes["Makefile"])
+
+	_, err = hashInputs(dir, []string{"*.go"}, CacheConfig{})
+	assert.EqualError(t, err, "input '*.go' does not match any files in '"+dir+"'")
+}
+
+func TestCachedCommand(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	assert.NoError(t, os.WriteFile(input, []byte("v1"), 0o600))
+
+	cache := CacheConfig{Dir: filepath.Join(dir, ".snex", "cache")}
+	marker := ParseMarker("insertCommand[echo hello inputs=input.txt]")
+
+	runs := 0
+	run := func() ([]string, error) {
+		runs++
+		return []string{"hello", "world"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		lines, err := cachedCommand(cache, []string{"echo", "hello"}, dir, nil, marker, []string{"input.txt"}, run)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"hello", "world"}, lines)
+	}
+	assert.Equal(t, 1, runs)
+
+	assert.NoError(t, os.WriteFile(input, []byte("v2"), 0o600))
+	_, err := cachedCommand(cache, []string{"echo", "hello"}, dir, nil, marker, []string{"input.txt"}, run)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, runs)
+
+	_, err = cachedCommand(CacheConfig{Dir: cache.Dir, Refresh: true}, []string{"echo", "hello"}, dir, nil, marker, []string{"input.txt"}, run)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, runs)
+
+	_, err = cachedCommand(cache, []string{"echo", "hello"}, dir, []string{"LANG=de"}, marker, []string{"input.txt"}, run)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, runs)
+}
+
+func TestCachedCommandWithoutInputs(t *testing.T) {
+	cache := CacheConfig{Dir: filepath.Join(t.TempDir(), "cache")}
+
+	runs := 0
+	run := func() ([]string, error) {
+		runs++
+		return []string{"hello"}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := cachedCommand(cache, []string{"echo", "hello"}, ".", nil, ParseMarker("insertCommand[echo hello]"), nil, run)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, runs)
+
+	_, err := os.Stat(cache.Dir)
+	assert.True(t, os.IsNotExist(err))
+}
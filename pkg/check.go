@@ -0,0 +1,275 @@
+package pkg
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	template2 "text/template"
+)
+
+var goMajorVersionExpression = regexp.MustCompile(`^v[0-9]+$`)
+
+// TestConfig configures how 'snex test' checks snippets
+type TestConfig struct {
+	// Harnesses maps file extensions to the harness for snippets from files with that extension
+	Harnesses map[string]Harness `json:"harnesses"`
+}
+
+// Harness wraps a snippet into a complete program that can be checked by Command
+type Harness struct {
+	// Template renders the snippet, see HarnessHelp for the available variables
+	Template string `json:"template"`
+	// File is the name of the rendered snippet inside of the temporary directory
+	File string `json:"file"`
+	// Files are additional files written to the temporary directory, like a 'go.mod'
+	Files map[string]string `json:"files"`
+	// Command is the check command run in the temporary directory, like 'go vet ./...'
+	Command string `json:"command"`
+	// Timeout is the timeout for Command like '60s'
+	Timeout string `json:"timeout"`
+}
+
+// SnippetCheck is the result of checking a single snippet
+type SnippetCheck struct {
+	Id   string
+	File string
+	Line int
+	// Skipped is set if no harness is configured for the snippet file or the snippet has the attribute 'test=false'
+	Skipped bool
+	Err     error
+}
+
+type harnessTemplateData struct {
+	Content      string
+	Filename     string
+	Language     string
+	Imports      string
+	Declarations bool
+}
+
+var HarnessHelp = "\t\t{{.Content}}\t\t snippet content\n" +
+	"\t\t{{.Filename}}\t\t file the snippet is defined in\n" +
+	"\t\t{{.Language}}\t\t language of the snippet file\n" +
+	"\t\t{{.Imports}}\t\t for Go, the import declaration for all imports of the snippet file used by the snippet\n" +
+	"\t\t{{.Declarations}}\t for Go, true if the snippet consists of top level declarations instead of statements\n"
+
+var DefaultHarnesses = map[string]Harness{
+	"go": {
+		Template: "{{if .Declarations}}package snippet{{else}}package main{{end}}\n\n{{.Imports}}\n\n" +
+			"{{if .Declarations}}{{.Content}}{{else}}func main() {\n{{.Content}}\n}{{end}}\n",
+		File:    "snippet.go",
+		Files:   map[string]string{"go.mod": "module snippet\n\ngo 1.19\n"},
+		Command: "go vet ./...",
+	},
+}
+
+func (config TestConfig) harness(extension string) (Harness, bool) {
+	if harness, ok := config.Harnesses[extension]; ok {
+		return harness, true
+	}
+
+	harness, ok := DefaultHarnesses[extension]
+	return harness, ok
+}
+
+// Validate checks the templates and commands of the harnesses
+func (config TestConfig) Validate() error {
+	for extension, harness := range config.Harnesses {
+		if _, err := template2.New("harness").Funcs(templateFunctions).Parse(harness.Template); err != nil {
+			return fmt.Errorf("invalid template for harness '%s': %s", extension, err)
+		}
+
+		if len(harness.File) == 0 || len(harness.Command) == 0 {
+			return fmt.Errorf("harness '%s' needs a file and a command", extension)
+		}
+
+		if _, _, err := splitCommandLine(harness.Command); err != nil {
+			return fmt.Errorf("invalid command for harness '%s': %s", extension, err)
+		}
+
+		if len(harness.Timeout) > 0 {
+			if _, err := parseCommandTimeout(harness.Timeout); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// goImportName returns the name a Go import is referenced by
+func goImportName(importPath string, alias string) string {
+	if len(alias) > 0 {
+		return alias
+	}
+
+	name := path.Base(importPath)
+	if goMajorVersionExpression.MatchString(name) && strings.Contains(importPath, "/") {
+		name = path.Base(path.Dir(importPath))
+	}
+
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// goSnippetImports returns an import declaration with the imports of the Go source file that are used by content
+func goSnippetImports(source string, content string) string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", source, parser.ImportsOnly)
+	if err != nil {
+		return ""
+	}
+
+	var imports []string
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+
+		alias := ""
+		if spec.Name != nil {
+			alias = spec.Name.Name
+		}
+
+		name := goImportName(importPath, alias)
+		if name == "_" || name == "." || !regexp.MustCompile(`\b`+regexp.QuoteMeta(name)+`\.`).MatchString(content) {
+			continue
+		}
+
+		imports = append(imports, "\t"+strings.TrimSpace(alias+" "+strconv.Quote(importPath)))
+	}
+
+	if len(imports) == 0 {
+		return ""
+	}
+
+	return "import (\n" + strings.Join(imports, "\n") + "\n)"
+}
+
+// isGoDeclarations reports whether content consists of top level Go declarations
+func isGoDeclarations(content string) bool {
+	_, err := parser.ParseFile(token.NewFileSet(), "", "package snippet\n"+content, parser.AllErrors)
+	return err == nil
+}
+
+func renderHarness(harness Harness, lines []string, file string, source string, vars map[string]string) (string, error) {
+	tmpl, err := template2.New("harness").Funcs(templateFunctions).Parse(harness.Template)
+	if err != nil {
+		return "", err
+	}
+
+	// the snippet is checked like it is inserted, with its '${{ snex.name }}' placeholders replaced
+	lines, err = replaceVars(lines, vars)
+	if err != nil {
+		return "", err
+	}
+
+	content := strings.Join(lines, "\n")
+	data := harnessTemplateData{Content: content, Filename: file, Language: languageForFile(file)}
+	if strings.HasSuffix(file, ".go") {
+		data.Imports = goSnippetImports(source, content)
+		data.Declarations = isGoDeclarations(content)
+	}
+
+	rendered := new(strings.Builder)
+	if err := tmpl.Execute(rendered, data); err != nil {
+		return "", err
+	}
+
+	return rendered.String(), nil
+}
+
+// checkSnippet renders the snippet into a temporary directory and runs the check command of the harness
+func checkSnippet(harness Harness, lines []string, file string, source string, vars map[string]string) error {
+	content, err := renderHarness(harness, lines, file, source, vars)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "snex-test-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{harness.File: content}
+	for name, fileContent := range harness.Files {
+		files[name] = fileContent
+	}
+
+	for name, fileContent := range files {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o700); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(fileContent), 0o600); err != nil {
+			return err
+		}
+	}
+
+	args, _, err := splitCommandLine(harness.Command)
+	if err != nil {
+		return err
+	}
+
+	// the attributes of the snippet marker must not change how the check is run, e.g. 'exit-code' would accept failures
+	_, err = executeCommand(args, dir, harness.Timeout, nil, &SnippetMarker{}, CacheConfig{}, nil)
+	return err
+}
+
+// CheckSnippets checks every snippet that is used by an 'insertSnippet' marker enabled for the active profiles with
+// the harness for the file extension of the file the snippet is defined in. The results are sorted by snippet
+// location.
+func CheckSnippets(documents []ParsedDocument, config Config) []SnippetCheck {
+	used := map[string]bool{}
+	for _, document := range documents {
+		for _, line := range document.Lines {
+			if line.Snippet == nil || line.Snippet.Kind != KindInsertSnippet || !line.Snippet.IsStart {
+				continue
+			}
+
+			if enabled, err := isInsertEnabled(documents, line.Snippet, config.Profiles.Active); err == nil && enabled {
+				used[line.Snippet.Id] = true
+			}
+		}
+	}
+
+	var checks []SnippetCheck
+	for _, document := range documents {
+		for _, line := range document.Lines {
+			marker := line.Snippet
+			if marker == nil || marker.Kind != KindSnippet || !marker.IsStart || !used[marker.Id] {
+				continue
+			}
+
+			check := SnippetCheck{Id: marker.Id, File: document.File, Line: line.number + 1}
+
+			harness, ok := config.Test.harness(strings.TrimPrefix(filepath.Ext(document.File), "."))
+			enabled, err := marker.BoolAttribute("test", true)
+
+			switch {
+			case err != nil:
+				check.Err = err
+			case !ok || !enabled:
+				check.Skipped = true
+			default:
+				source := strings.Join(getContentForFile(documents, document.File), "\n")
+				check.Err = checkSnippet(harness, removeIndentation(getSnippetLines(documents, marker.Id)), document.File, source, config.Vars)
+			}
+
+			checks = append(checks, check)
+		}
+	}
+
+	sort.SliceStable(checks, func(i, j int) bool {
+		if checks[i].File != checks[j].File {
+			return checks[i].File < checks[j].File
+		}
+		return checks[i].Line < checks[j].Line
+	})
+
+	return checks
+}
@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"os/exec"
+	"testing"
+)
+
+var checkGoSource = `package main
+
+import (
+	"fmt"
+	str "strings"
+	"github.com/urfave/cli/v2"
+)
+
+func main() {
+	// snippet[check-statements]
+	fmt.Println(str.ToUpper("hello"))
+	// /snippet
+
+	// snippet[check-broken]
+	fmt.Println(undefinedVariable)
+	// /snippet
+
+	// snippet[check-skipped test=false]
+	fmt.Println(undefinedVariable)
+	// /snippet
+}
+
+// snippet[check-declarations]
+func greet(name string) string {
+	return fmt.Sprintf("Hello %s", name)
+}
+// /snippet
+
+// snippet[check-unused]
+// /snippet
+`
+
+func TestGoSnippetImports(t *testing.T) {
+	assert.Equal(t, "import (\n\t\"fmt\"\n\tstr \"strings\"\n)", goSnippetImports(checkGoSource, `fmt.Println(str.ToUpper("hello"))`))
+	assert.Equal(t, "import (\n\t\"github.com/urfave/cli/v2\"\n)", goSnippetImports(checkGoSource, `app := &cli.App{}`))
+	assert.Equal(t, "", goSnippetImports(checkGoSource, `x := 1`))
+}
+
+func TestIsGoDeclarations(t *testing.T) {
+	assert.True(t, isGoDeclarations("func greet() {}\n\ntype Greeter struct{}"))
+	assert.False(t, isGoDeclarations("x := 1\nfmt.Println(x)"))
+}
+
+func TestRenderHarness(t *testing.T) {
+	content, err := renderHarness(DefaultHarnesses["go"], []string{`fmt.Println(str.ToUpper("hello"))`}, "main.go", checkGoSource, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "package main\n\nimport (\n\t\"fmt\"\n\tstr \"strings\"\n)\n\nfunc main() {\nfmt.Println(str.ToUpper(\"hello\"))\n}\n", content)
+}
+
+func TestRenderHarnessVars(t *testing.T) {
+	content, err := renderHarness(DefaultHarnesses["go"], []string{`fmt.Println("${{ snex.greeting }}")`}, "main.go", checkGoSource, map[string]string{"greeting": "hello"})
+	assert.NoError(t, err)
+	assert.Equal(t, "package main\n\nimport (\n\t\"fmt\"\n)\n\nfunc main() {\nfmt.Println(\"hello\")\n}\n", content)
+
+	_, err = renderHarness(DefaultHarnesses["go"], []string{`fmt.Println("${{ snex.greeting }}")`}, "main.go", checkGoSource, nil)
+	assert.EqualError(t, err, "unknown variable 'greeting'")
+}
+
+func TestCheckSnippetsProfiles(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	source, err := ParseDocument(Document{File: "scripts/check.sh", Content: "# snippet[check-enterprise tags=enterprise]\nexit 0\n# /snippet\n# snippet[check-oss]\nexit 0\n# /snippet"})
+	assert.NoError(t, err)
+
+	target, err := ParseDocument(Document{File: "README.md", Content: "insertSnippet[check-enterprise]\n/insertSnippet\ninsertSnippet[check-oss if=!enterprise]\n/insertSnippet"})
+	assert.NoError(t, err)
+
+	config := Config{Test: TestConfig{Harnesses: map[string]Harness{"sh": {Template: "{{.Content}}\n", File: "snippet.sh", Command: "sh snippet.sh"}}}}
+	checks := CheckSnippets([]ParsedDocument{source, target}, config)
+	assert.Equal(t, 1, len(checks))
+	assert.Equal(t, "check-oss", checks[0].Id)
+
+	config.Profiles.Active = []string{"enterprise"}
+	checks = CheckSnippets([]ParsedDocument{source, target}, config)
+	assert.Equal(t, 1, len(checks))
+	assert.Equal(t, "check-enterprise", checks[0].Id)
+}
+
+func TestCheckSnippetsIgnoresMarkerAttributes(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	source, err := ParseDocument(Document{File: "scripts/fail.sh", Content: "# snippet[check-exit-code exit-code stderr]\nexit 3\n# /snippet"})
+	assert.NoError(t, err)
+
+	target, err := ParseDocument(Document{File: "README.md", Content: "insertSnippet[check-exit-code]\n/insertSnippet"})
+	assert.NoError(t, err)
+
+	config := Config{Test: TestConfig{Harnesses: map[string]Harness{"sh": {Template: "{{.Content}}\n", File: "snippet.sh", Command: "sh snippet.sh"}}}}
+	checks := CheckSnippets([]ParsedDocument{source, target}, config)
+	assert.Equal(t, 1, len(checks))
+	assert.EqualError(t, checks[0].Err, "command 'sh snippet.sh' failed with exit code 3: ")
+}
+
+func TestCheckSnippets(t *testing.T) {
+	source, err := ParseDocument(Document{File: "cmd/main.go", Content: checkGoSource})
+	assert.NoError(t, err)
+
+	target, err := ParseDocument(Document{File: "README.md", Content: "insertSnippet[check-statements]\n/insertSnippet\ninsertSnippet[check-broken]\n/insertSnippet\ninsertSnippet[check-skipped]\n/insertSnippet\ninsertSnippet[check-declarations]\n/insertSnippet"})
+	assert.NoError(t, err)
+
+	checks := CheckSnippets([]ParsedDocument{source, target}, Config{})
+	assert.Equal(t, 4, len(checks))
+
+	assert.Equal(t, "check-statements", checks[0].Id)
+	assert.Equal(t, 10, checks[0].Line)
+	assert.NoError(t, checks[0].Err)
+
+	assert.Equal(t, "check-broken", checks[1].Id)
+	assert.Error(t, checks[1].Err)
+
+	assert.Equal(t, "check-skipped", checks[2].Id)
+	assert.True(t, checks[2].Skipped)
+
+	assert.Equal(t, "check-declarations", checks[3].Id)
+	assert.NoError(t, checks[3].Err)
+}
@@ -0,0 +1,310 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultCommandTimeout = 10 * time.Second
+
+// commandAttributes are the attribute names that are split off the end of an 'insertCommand' marker, all other
+// tokens are part of the command
+var commandAttributes = []string{"timeout", "dir", "stderr", "exit-code", "inputs", "escape", "dedent", "indent", "tabs-to-spaces", "links", "if"}
+
+var commandAttributeExpression = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9_\-]*)(?:=(?:"([^"]*)"|(.*)))?$`)
+
+// commandEnvironment are the variables passed on from the environment snex is running in, everything else is
+// removed to make the output reproducible
+var commandEnvironment = []string{"PATH", "HOME", "TMPDIR", "SYSTEMROOT", "GOPATH", "GOCACHE", "GOMODCACHE", "GOPROXY", "GOFLAGS"}
+
+func parseCommandTimeout(timeout string) (time.Duration, error) {
+	duration, err := time.ParseDuration(timeout)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid timeout '%s'", timeout)
+	}
+
+	return duration, nil
+}
+
+// splitCommandLine splits a command line into its arguments, where single or double quotes group an argument
+// containing whitespace. The returned offsets are the start of each argument inside of line.
+func splitCommandLine(line string) ([]string, []int, error) {
+	var args []string
+	var offsets []int
+
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	for index, char := range line {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else {
+				current.WriteRune(char)
+			}
+		case char == '"' || char == '\'':
+			quote = char
+			if !inArg {
+				inArg = true
+				offsets = append(offsets, index)
+			}
+		case char == ' ' || char == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(char)
+			if !inArg {
+				inArg = true
+				offsets = append(offsets, index)
+			}
+		}
+	}
+
+	if quote != 0 {
+		return nil, nil, fmt.Errorf("unterminated quote in '%s'", line)
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, offsets, nil
+}
+
+// parseCommandMarker splits the content of an 'insertCommand[...]' marker into the command and the known attributes
+// at its end, so 'make help timeout=5s' becomes the command 'make help' with the attribute timeout=5s
+func parseCommandMarker(content string) (string, map[string]string) {
+	attributes := map[string]string{}
+
+	args, offsets, err := splitCommandLine(content)
+	if err != nil {
+		return strings.TrimSpace(content), attributes
+	}
+
+	end := len(content)
+	for index := len(args) - 1; index > 0; index-- {
+		match := commandAttributeExpression.FindStringSubmatch(strings.TrimSpace(content[offsets[index]:end]))
+		if match == nil || !contains(commandAttributes, match[1]) {
+			break
+		}
+
+		if len(match[2]) > 0 {
+			attributes[match[1]] = match[2]
+		} else {
+			attributes[match[1]] = match[3]
+		}
+
+		end = offsets[index]
+	}
+
+	return strings.TrimSpace(content[:end]), attributes
+}
+
+// isCommandAllowed reports whether command is allowed by one of the allowlist entries, where an entry allows the
+// command itself and the command with additional arguments
+func isCommandAllowed(allow []string, command string) bool {
+	for _, allowed := range allow {
+		allowed = strings.TrimSpace(allowed)
+		if len(allowed) > 0 && (command == allowed || strings.HasPrefix(command, allowed+" ")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runCommand runs the command of an 'insertCommand' marker if it is allowed by the config, the output is cached if
+// input files are declared with the 'inputs' attribute
+func runCommand(config CommandConfig, cache CacheConfig, marker *SnippetMarker, target string) ([]string, error) {
+	if len(config.Allow) == 0 {
+		return nil, fmt.Errorf("running commands is disabled, allow the command in the config file to enable it")
+	}
+
+	if !isCommandAllowed(config.Allow, marker.Id) {
+		return nil, fmt.Errorf("command '%s' is not allowed, allowed commands are: %s", marker.Id, strings.Join(config.Allow, ", "))
+	}
+
+	args, _, err := splitCommandLine(marker.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := commandDir(target, marker.Attribute("dir", "."))
+	if err != nil {
+		return nil, err
+	}
+
+	return executeCommand(args, dir, config.Timeout, config.Env, marker, cache, parseInputPatterns(marker.Attribute("inputs", "")))
+}
+
+// repositoryRoot returns the closest directory containing dir that contains a '.git' entry, or dir itself if there
+// is none
+func repositoryRoot(dir string) string {
+	for current := dir; ; current = filepath.Dir(current) {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current
+		}
+
+		if filepath.Dir(current) == current {
+			return dir
+		}
+	}
+}
+
+// commandDir resolves the directory a command is run in relative to the target file. The directory can not be
+// outside of the repository of the target file, otherwise an allowed command like './gen.sh' could run any 'gen.sh'.
+func commandDir(target string, dir string) (string, error) {
+	targetDir, err := filepath.Abs(filepath.Dir(target))
+	if err != nil {
+		return "", err
+	}
+
+	root := repositoryRoot(targetDir)
+	resolved := filepath.Join(targetDir, dir)
+
+	relative, err := filepath.Rel(root, resolved)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) || filepath.IsAbs(dir) {
+		return "", fmt.Errorf("dir '%s' is outside of the repository '%s'", dir, root)
+	}
+
+	return resolved, nil
+}
+
+// commandEnv returns the controlled environment for commands with the additional variables from env
+func commandEnv(env map[string]string) []string {
+	var result []string
+	for _, variable := range commandEnvironment {
+		if value, ok := os.LookupEnv(variable); ok {
+			result = append(result, variable+"="+value)
+		}
+	}
+	result = append(result, "LC_ALL=C", "NO_COLOR=1", "TERM=dumb")
+
+	var variables []string
+	for variable := range env {
+		variables = append(variables, variable)
+	}
+	sort.Strings(variables)
+
+	for _, variable := range variables {
+		result = append(result, variable+"="+env[variable])
+	}
+
+	return result
+}
+
+// executeCommand runs args without a shell in dir and returns its stdout, which is cached as long as the files
+// matching the input patterns do not change. The marker attribute 'timeout' overrides defaultTimeout, 'stderr' adds
+// the output to stderr, 'exit-code' adds the exit code of the command and accepts non-zero exit codes.
+func executeCommand(args []string, dir string, defaultTimeout string, env map[string]string, marker *SnippetMarker, cache CacheConfig, inputs []string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no command specified")
+	}
+
+	return cachedCommand(cache, args, dir, commandEnv(env), marker, inputs, func() ([]string, error) {
+		return runProcess(args, dir, defaultTimeout, commandEnv(env), marker)
+	})
+}
+
+func runProcess(args []string, dir string, defaultTimeout string, env []string, marker *SnippetMarker) ([]string, error) {
+	name := strings.Join(args, " ")
+
+	var err error
+	timeout := defaultCommandTimeout
+	if len(defaultTimeout) > 0 {
+		if timeout, err = parseCommandTimeout(defaultTimeout); err != nil {
+			return nil, err
+		}
+	}
+	if value := marker.Attribute("timeout", ""); len(value) > 0 {
+		if timeout, err = parseCommandTimeout(value); err != nil {
+			return nil, err
+		}
+	}
+
+	includeStderr, err := marker.BoolAttribute("stderr", false)
+	if err != nil {
+		return nil, err
+	}
+
+	includeExitCode, err := marker.BoolAttribute("exit-code", false)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	command := exec.CommandContext(ctx, args[0], args[1:]...)
+	command.Dir = dir
+	command.Env = env
+
+	// the output is written to files instead of pipes, otherwise child processes that keep the output open would
+	// block waiting for the command after the timeout killed it
+	output, err := os.CreateTemp("", "snex-output-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	stderr, err := os.CreateTemp("", "snex-stderr-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(stderr.Name())
+	defer stderr.Close()
+
+	command.Stdout = output
+	command.Stderr = stderr
+	if includeStderr {
+		command.Stderr = output
+	}
+
+	exitCode := 0
+	err = command.Run()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("command '%s' timed out after %s", name, timeout)
+	}
+
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		exitCode = exitError.ExitCode()
+		if !includeExitCode {
+			return nil, fmt.Errorf("command '%s' failed with exit code %d: %s", name, exitCode, strings.TrimSpace(readOutput(stderr)))
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("command '%s' failed: %s", name, err)
+	}
+
+	lines := strings.Split(strings.TrimRight(readOutput(output), "\n"), "\n")
+	if includeExitCode {
+		lines = append(lines, fmt.Sprintf("exit code: %d", exitCode))
+	}
+
+	return lines, nil
+}
+
+// readOutput returns everything written to the output file of a process
+func readOutput(file *os.File) string {
+	content, err := os.ReadFile(file.Name())
+	if err != nil {
+		return ""
+	}
+
+	return string(content)
+}
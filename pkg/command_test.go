@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"fmt"
+	"github.com/alecthomas/assert/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	args, offsets, err := splitCommandLine(`./mytool --name "hello world" 'a b'`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"./mytool", "--name", "hello world", "a b"}, args)
+	assert.Equal(t, []int{0, 9, 16, 30}, offsets)
+
+	_, _, err = splitCommandLine(`echo "hello`)
+	assert.Error(t, err)
+}
+
+func TestParseCommandMarker(t *testing.T) {
+	command, attributes := parseCommandMarker("make help")
+	assert.Equal(t, "make help", command)
+	assert.Equal(t, map[string]string{}, attributes)
+
+	command, attributes = parseCommandMarker(` make VERBOSE=1 help timeout=5s dir="../sub dir" stderr `)
+	assert.Equal(t, "make VERBOSE=1 help", command)
+	assert.Equal(t, map[string]string{"timeout": "5s", "dir": "../sub dir", "stderr": ""}, attributes)
+}
+
+func TestParseMarkerInsertCommand(t *testing.T) {
+	marker := ParseMarker(`<!-- insertCommand[./mytool --help exit-code] -->`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertCommand, marker.Kind)
+	assert.True(t, marker.IsStart)
+	assert.Equal(t, "./mytool --help", marker.Id)
+	assert.Equal(t, map[string]string{"exit-code": ""}, marker.Attributes)
+
+	assert.Equal(t, KindInsertCommand, ParseMarker(`<!-- /insertCommand -->`).Kind)
+}
+
+func TestIsCommandAllowed(t *testing.T) {
+	allow := []string{"make help", "./mytool"}
+
+	assert.True(t, isCommandAllowed(allow, "make help"))
+	assert.True(t, isCommandAllowed(allow, "./mytool --help"))
+	assert.False(t, isCommandAllowed(allow, "make install"))
+	assert.False(t, isCommandAllowed(allow, "./mytool2"))
+	assert.False(t, isCommandAllowed(nil, "make help"))
+}
+
+func TestRunCommand(t *testing.T) {
+	config := CommandConfig{Allow: []string{"echo", "ls", "printenv"}, Env: map[string]string{"GREETING": "hello"}}
+
+	lines, err := runCommand(config, CacheConfig{}, ParseMarker("insertCommand[echo hello world]"), "README.md")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello world"}, lines)
+
+	_, err = runCommand(config, CacheConfig{}, ParseMarker("insertCommand[ls does-not-exist]"), "README.md")
+	assert.Error(t, err)
+
+	lines, err = runCommand(config, CacheConfig{}, ParseMarker("insertCommand[ls does-not-exist exit-code]"), "README.md")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "exit code: 0", lines[len(lines)-1])
+
+	lines, err = runCommand(config, CacheConfig{}, ParseMarker("insertCommand[printenv GREETING]"), "README.md")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello"}, lines)
+}
+
+func TestRunCommandNotAllowed(t *testing.T) {
+	_, err := runCommand(CommandConfig{}, CacheConfig{}, ParseMarker("insertCommand[echo hello]"), "README.md")
+	assert.EqualError(t, err, "running commands is disabled, allow the command in the config file to enable it")
+
+	_, err = runCommand(CommandConfig{Allow: []string{"make help"}}, CacheConfig{}, ParseMarker("insertCommand[echo hello]"), "README.md")
+	assert.EqualError(t, err, "command 'echo hello' is not allowed, allowed commands are: make help")
+}
+
+func TestCommandDir(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(root, ".git"), 0755))
+	target := filepath.Join(root, "docs", "README.md")
+
+	dir, err := commandDir(target, "../tool")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "tool"), dir)
+
+	_, err = commandDir(target, "../..")
+	assert.EqualError(t, err, fmt.Sprintf("dir '../..' is outside of the repository '%s'", root))
+
+	_, err = commandDir(target, "/tmp")
+	assert.Error(t, err)
+}
+
+func TestRunProcessTimeoutWithChildProcess(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	// the background process keeps stdout open after the shell was killed by the timeout
+	start := time.Now()
+	_, err := runProcess([]string{"sh", "-c", "sleep 10 & sleep 10"}, ".", "100ms", nil, &SnippetMarker{})
+	assert.EqualError(t, err, "command 'sh -c sleep 10 & sleep 10' timed out after 100ms")
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestReplaceSnippetsInsertCommand(t *testing.T) {
+	document, err := ParseDocument(Document{File: "README.md", Content: "<!-- insertCommand[echo hello] -->\n<!-- /insertCommand -->"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0, len(ValidateDocuments([]ParsedDocument{document})))
+
+	documents, err := ReplaceSnippetsWithConfig([]ParsedDocument{document}, "{{.Content}}", Config{Commands: CommandConfig{Allow: []string{"echo"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "<!-- insertCommand[echo hello] -->\nhello\n<!-- /insertCommand -->", documents[0].Content)
+}
@@ -0,0 +1,121 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultConfigFile is the config file that is used if it exists and no other config file is given
+const DefaultConfigFile = ".snex.json"
+
+// Config is the optional snex configuration, read from a JSON file like
+//
+//	{
+//	  "commands": {
+//	    "allow": ["make help", "./mytool"],
+//	    "timeout": "30s"
+//	  },
+//	  "output": {
+//	    "enabled": true,
+//	    "runners": {"py": "python3 {file}"},
+//	    "scrub": ["timestamp"]
+//	  }
+//	}
+type Config struct {
+	Commands CommandConfig `json:"commands"`
+	Output   OutputConfig  `json:"output"`
+	Test     TestConfig    `json:"test"`
+	Cache    CacheConfig   `json:"cache"`
+	Tree     TreeConfig    `json:"tree"`
+	// Values are the sources for inline value markers by name
+	Values map[string]ValueSource `json:"values"`
+	// Vars are available in templates as '{{.Vars.name}}' and replace '${{ snex.name }}' placeholders in inserted content
+	Vars     map[string]string `json:"vars"`
+	Profiles ProfileConfig     `json:"profiles"`
+	// Templates are used in addition to DefaultSnippetTemplates and win over them on equal priority
+	Templates []SnippetTemplate `json:"templates"`
+}
+
+// CommandConfig configures which commands can be run by 'insertCommand' and how, running commands is disabled as
+// long as Allow is empty
+type CommandConfig struct {
+	// Allow lists the allowed commands, an entry allows the command itself and the command with additional arguments
+	Allow []string `json:"allow"`
+	// Timeout is the default timeout for commands like '10s'
+	Timeout string `json:"timeout"`
+	// Env sets additional environment variables for commands
+	Env map[string]string `json:"env"`
+}
+
+// SnippetTemplates returns the configured templates followed by DefaultSnippetTemplates
+func (config *Config) SnippetTemplates() []SnippetTemplate {
+	return append(append([]SnippetTemplate{}, config.Templates...), DefaultSnippetTemplates...)
+}
+
+// LoadConfig reads the config from file
+func LoadConfig(file string) (*Config, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %s", file, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file '%s': %s", file, err)
+	}
+
+	return config, nil
+}
+
+// Validate checks the config values that can not be checked while decoding
+func (config *Config) Validate() error {
+	if len(config.Commands.Timeout) > 0 {
+		if _, err := parseCommandTimeout(config.Commands.Timeout); err != nil {
+			return err
+		}
+	}
+
+	for name := range config.Vars {
+		if err := validateVarName(name); err != nil {
+			return err
+		}
+	}
+
+	for _, profile := range config.Profiles.Active {
+		if _, err := parseCondition(profile); err != nil || strings.HasPrefix(profile, "!") {
+			return fmt.Errorf("invalid profile '%s'", profile)
+		}
+	}
+
+	if err := config.Profiles.Validate(); err != nil {
+		return err
+	}
+
+	for name, source := range config.Values {
+		if err := source.Validate(); err != nil {
+			return fmt.Errorf("invalid value '%s': %s", name, err)
+		}
+	}
+
+	for _, template := range config.Templates {
+		if err := template.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := config.Output.Validate(); err != nil {
+		return err
+	}
+
+	return config.Test.Validate()
+}
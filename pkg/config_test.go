@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfigTest(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), DefaultConfigFile)
+	assert.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+	return file
+}
+
+func TestLoadConfig(t *testing.T) {
+	config, err := LoadConfig(writeConfigTest(t, `{"commands": {"allow": ["make help"], "timeout": "30s"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"make help"}, config.Commands.Allow)
+	assert.Equal(t, "30s", config.Commands.Timeout)
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	_, err := LoadConfig(writeConfigTest(t, `{"command": {}}`))
+	assert.Error(t, err)
+
+	_, err = LoadConfig(writeConfigTest(t, `{"commands": {"timeout": "soon"}}`))
+	assert.Error(t, err)
+
+	_, err = LoadConfig(writeConfigTest(t, `{"vars": {"product-name": "snex"}}`))
+	assert.Error(t, err)
+}
+
+func TestLoadConfigTemplates(t *testing.T) {
+	config, err := LoadConfig(writeConfigTest(t, `{"templates": [{"name": "Docs", "template": "{{.Content}}", "patterns": ["docs/*.md"], "priority": 1}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []SnippetTemplate{{Name: "Docs", Template: "{{.Content}}", Patterns: []string{"docs/*.md"}, Priority: 1}}, config.Templates)
+
+	_, err = LoadConfig(writeConfigTest(t, `{"templates": [{"name": "Docs", "template": "{{.Content}}"}]}`))
+	assert.Error(t, err)
+
+	_, err = LoadConfig(writeConfigTest(t, `{"templates": [{"name": "Docs", "template": "{{.Content", "patterns": ["*.md"]}]}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,237 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// readCsvTable reads the header and the rows of a CSV file, or of a TSV file if tabs are used as separator
+func readCsvTable(content string, separator rune) ([]string, [][]string, error) {
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = separator
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("no header row found")
+	}
+
+	return records[0], records[1:], nil
+}
+
+// jsonCell converts a JSON value to a table cell, strings are inserted without quotes, null as empty cell and
+// objects and arrays as compact JSON
+func jsonCell(raw json.RawMessage) string {
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value
+	}
+
+	if string(raw) == "null" {
+		return ""
+	}
+
+	compact := new(bytes.Buffer)
+	if err := json.Compact(compact, raw); err != nil {
+		return string(raw)
+	}
+
+	return compact.String()
+}
+
+// readJsonTable reads a JSON array of objects, the columns are the object members in the order of their first
+// appearance
+func readJsonTable(raw json.RawMessage) ([]string, [][]string, error) {
+	var objects []json.RawMessage
+	if err := json.Unmarshal(raw, &objects); err != nil {
+		return nil, nil, fmt.Errorf("expected an array of objects")
+	}
+
+	var headers []string
+	var values []map[string]string
+
+	for _, object := range objects {
+		decoder := json.NewDecoder(bytes.NewReader(object))
+		if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+			return nil, nil, fmt.Errorf("expected an array of objects")
+		}
+
+		row := map[string]string{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return nil, nil, err
+			}
+
+			name := fmt.Sprint(key)
+			if !contains(headers, name) {
+				headers = append(headers, name)
+			}
+			row[name] = jsonCell(value)
+		}
+
+		values = append(values, row)
+	}
+
+	var rows [][]string
+	for _, value := range values {
+		var row []string
+		for _, header := range headers {
+			row = append(row, value[header])
+		}
+		rows = append(rows, row)
+	}
+
+	return headers, rows, nil
+}
+
+// readDataTable reads the table from a CSV, TSV or JSON file, for JSON files the array can be selected with a JSON
+// pointer like 'limits.json#/limits'
+func readDataTable(documents []ParsedDocument, id string) ([]string, [][]string, error) {
+	file, pointer := splitFileId(id)
+
+	if getDocumentForFile(documents, file) == nil {
+		return nil, nil, fmt.Errorf("file '%s' not found", file)
+	}
+
+	content := strings.Join(getContentForFile(documents, file), "\n")
+
+	switch {
+	case matchPattern("*.csv", file):
+		return readCsvTable(content, ',')
+	case matchPattern("*.tsv", file):
+		return readCsvTable(content, '\t')
+	case matchPattern("*.json", file):
+		tokens, err := parseJsonPointer(pointer)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		raw := json.RawMessage(content)
+		for index, token := range tokens {
+			if raw, err = jsonChild(raw, token); err != nil {
+				return nil, nil, fmt.Errorf("could not resolve '%s': %s", formatJsonPointer(tokens[:index+1]), err)
+			}
+		}
+
+		return readJsonTable(raw)
+	}
+
+	return nil, nil, fmt.Errorf("unsupported file '%s', tables can be read from *.csv, *.tsv and *.json files", file)
+}
+
+// compareCells compares two cells numerically if both are numbers and as text otherwise
+func compareCells(a string, b string) int {
+	numberA, errA := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	numberB, errB := strconv.ParseFloat(strings.TrimSpace(b), 64)
+
+	if errA == nil && errB == nil {
+		switch {
+		case numberA < numberB:
+			return -1
+		case numberA > numberB:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(a, b)
+}
+
+// extractTable renders a table from a CSV, TSV or JSON file referenced by an 'insertTable' marker in the format of
+// the target file. The attribute 'columns' selects and renames columns like 'name:Name,limit', 'sort' sorts the rows
+// by a column, descending if prefixed with '-', and 'escape-cells=false' inserts cells without escaping.
+func extractTable(documents []ParsedDocument, marker *SnippetMarker, target string) ([]string, error) {
+	headers, rows, err := readDataTable(documents, marker.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := tableFormat(marker, target)
+	if err != nil {
+		return nil, err
+	}
+
+	escapeCells, err := marker.BoolAttribute("escape-cells", true)
+	if err != nil {
+		return nil, err
+	}
+
+	indexOf := func(key string) int {
+		for index, header := range headers {
+			if header == key {
+				return index
+			}
+		}
+		return -1
+	}
+
+	if sortColumn := marker.Attribute("sort", ""); len(sortColumn) > 0 {
+		descending := strings.HasPrefix(sortColumn, "-")
+		column := indexOf(strings.TrimPrefix(sortColumn, "-"))
+		if column == -1 {
+			return nil, fmt.Errorf("unknown sort column '%s', available columns are: %s", strings.TrimPrefix(sortColumn, "-"), strings.Join(headers, ", "))
+		}
+
+		sort.SliceStable(rows, func(i, j int) bool {
+			cellI, cellJ := "", ""
+			if column < len(rows[i]) {
+				cellI = rows[i][column]
+			}
+			if column < len(rows[j]) {
+				cellJ = rows[j][column]
+			}
+
+			if descending {
+				return compareCells(cellI, cellJ) > 0
+			}
+			return compareCells(cellI, cellJ) < 0
+		})
+	}
+
+	if columnsAttribute, ok := marker.Attributes["columns"]; ok {
+		columns := parseTableColumns(columnsAttribute)
+
+		var selectedHeaders []string
+		var indices []int
+		for _, column := range columns {
+			index := indexOf(column.Key)
+			if index == -1 {
+				return nil, fmt.Errorf("unknown column '%s', available columns are: %s", column.Key, strings.Join(headers, ", "))
+			}
+			selectedHeaders = append(selectedHeaders, column.Header)
+			indices = append(indices, index)
+		}
+
+		var selectedRows [][]string
+		for _, row := range rows {
+			var selectedRow []string
+			for _, index := range indices {
+				cell := ""
+				if index < len(row) {
+					cell = row[index]
+				}
+				selectedRow = append(selectedRow, cell)
+			}
+			selectedRows = append(selectedRows, selectedRow)
+		}
+
+		headers, rows = selectedHeaders, selectedRows
+	}
+
+	return renderTable(format, headers, rows, escapeCells), nil
+}
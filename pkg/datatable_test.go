@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+var limitsCsvSource = `endpoint,limit,burst
+/users,100,"10"
+/orders,20,5
+/health,1000,
+`
+
+var limitsJsonSource = `{"limits": [
+  {"endpoint": "/users", "limit": 100, "tags": ["a", "b"]},
+  {"endpoint": "/orders", "limit": 20, "note": null, "burst": 5}
+]}`
+
+func extractTableTest(t *testing.T, target string, marker string) ([]string, error) {
+	csv, err := ParseDocument(Document{File: "data/limits.csv", Content: limitsCsvSource})
+	assert.NoError(t, err)
+
+	json, err := ParseDocument(Document{File: "data/limits.json", Content: limitsJsonSource})
+	assert.NoError(t, err)
+
+	return extractTable([]ParsedDocument{csv, json}, ParseMarker(marker), target)
+}
+
+func TestExtractTableCsv(t *testing.T) {
+	lines, err := extractTableTest(t, "README.md", "insertTable[data/limits.csv]")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"| endpoint | limit | burst |",
+		"|---|---|---|",
+		"| /users | 100 | 10 |",
+		"| /orders | 20 | 5 |",
+		"| /health | 1000 |  |",
+	}, lines)
+}
+
+func TestExtractTableColumnsAndSort(t *testing.T) {
+	lines, err := extractTableTest(t, "README.md", `insertTable[limits.csv columns="endpoint:Endpoint,limit:Requests per minute" sort=-limit]`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"| Endpoint | Requests per minute |",
+		"|---|---|",
+		"| /health | 1000 |",
+		"| /users | 100 |",
+		"| /orders | 20 |",
+	}, lines)
+}
+
+func TestExtractTableJsonAsciiDoc(t *testing.T) {
+	lines, err := extractTableTest(t, "docs/limits.adoc", "insertTable[limits.json#/limits sort=endpoint]")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"|===",
+		"| endpoint | limit | tags | note | burst",
+		"",
+		"| /orders | 20 |  |  | 5",
+		`| /users | 100 | ["a","b"] |  | `,
+		"|===",
+	}, lines)
+}
+
+func TestExtractTableErrors(t *testing.T) {
+	_, err := extractTableTest(t, "README.md", "insertTable[limits.csv columns=yolo]")
+	assert.EqualError(t, err, "unknown column 'yolo', available columns are: endpoint, limit, burst")
+
+	_, err = extractTableTest(t, "README.md", "insertTable[limits.csv sort=yolo]")
+	assert.EqualError(t, err, "unknown sort column 'yolo', available columns are: endpoint, limit, burst")
+
+	_, err = extractTableTest(t, "README.md", "insertTable[limits.json]")
+	assert.EqualError(t, err, "expected an array of objects")
+
+	_, err = extractTableTest(t, "README.md", "insertTable[limits.csv format=pdf]")
+	assert.EqualError(t, err, "unknown format 'pdf', available formats are: markdown, asciidoc, html")
+}
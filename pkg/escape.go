@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"html"
+	"strings"
+)
+
+// SnippetEscape defines the escaping that is applied to snippet content inserted into target files matching one of
+// the Patterns, see matchPattern for the pattern syntax
+type SnippetEscape struct {
+	Escape   string
+	Patterns []string
+}
+
+var DefaultSnippetEscapes = []SnippetEscape{
+	{Escape: "html", Patterns: []string{"*.html", "*.htm"}},
+	{Escape: "xml", Patterns: []string{"*.xml", "*.xhtml", "*.svg"}},
+}
+
+var escapers = map[string]func(content string) string{
+	"none": func(content string) string { return content },
+	"html": html.EscapeString,
+	"xml":  html.EscapeString,
+	"json": escapeJsonString,
+	"yaml": escapeJsonString,
+	"go":   escapeGoRawString,
+}
+
+var EscapeHelp = "none, html, xml, json, yaml, go"
+
+func isValidEscape(escape string) bool {
+	_, ok := escapers[escape]
+	return ok
+}
+
+func escapeForFile(file string) string {
+	for _, escape := range DefaultSnippetEscapes {
+		if matchAnyPattern(escape.Patterns, file) {
+			return escape.Escape
+		}
+	}
+
+	return "none"
+}
+
+func escapeContent(content string, escape string) string {
+	escaper, ok := escapers[escape]
+	if !ok {
+		return content
+	}
+
+	return escaper(content)
+}
+
+// escapeJsonString escapes content so it can be used inside of a double-quoted JSON or YAML string
+func escapeJsonString(content string) string {
+	buffer := new(bytes.Buffer)
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+
+	err := encoder.Encode(content)
+	if err != nil {
+		return content
+	}
+
+	escaped := strings.TrimSuffix(buffer.String(), "\n")
+	return escaped[1 : len(escaped)-1]
+}
+
+// escapeGoRawString escapes content so it can be used inside of a Go raw string literal
+func escapeGoRawString(content string) string {
+	return strings.ReplaceAll(content, "`", "` + \"`\" + `")
+}
@@ -0,0 +1,28 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+func TestEscapeHtml(t *testing.T) {
+	assert.Equal(t, "if a &lt; b &amp;&amp; c &gt; d", escapeContent("if a < b && c > d", "html"))
+}
+
+func TestEscapeJson(t *testing.T) {
+	assert.Equal(t, `println(\"<a>\")\n\tline2`, escapeContent("println(\"<a>\")\n\tline2", "json"))
+}
+
+func TestEscapeGoRawString(t *testing.T) {
+	assert.Equal(t, "a ` + \"`\" + `b` + \"`\" + ` c", escapeContent("a `b` c", "go"))
+}
+
+func TestEscapeNone(t *testing.T) {
+	assert.Equal(t, "<a>", escapeContent("<a>", "none"))
+}
+
+func TestEscapeForFile(t *testing.T) {
+	assert.Equal(t, "html", escapeForFile("docs/index.html"))
+	assert.Equal(t, "xml", escapeForFile("pom.xml"))
+	assert.Equal(t, "none", escapeForFile("README.md"))
+}
@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var lineRangeExpression = regexp.MustCompile(`^L(\d+)(?:-L?(\d+))?$`)
+
+// splitFileId splits a file reference like 'main.go#L10-L42' into the file 'main.go' and the fragment 'L10-L42'
+func splitFileId(id string) (string, string) {
+	index := strings.Index(id, "#")
+	if index < 0 {
+		return id, ""
+	}
+
+	return id[:index], id[index+1:]
+}
+
+// extractLines returns the lines selected by a GitHub style line range fragment like 'L10-L42' or 'L10', followed by
+// the lines selected by the 'from' and 'to' regular expression attributes of the marker. The lines matching 'from'
+// and 'to' are included unless the marker attribute 'bounds' is set to 'exclusive'.
+func extractLines(lines []string, fragment string, marker *SnippetMarker) ([]string, error) {
+	if len(fragment) > 0 {
+		start, end, err := parseLineRange(fragment)
+		if err != nil {
+			return nil, err
+		}
+
+		if start < 1 || end > len(lines) || start > end {
+			return nil, fmt.Errorf("line range '%s' is out of bounds, file has %d lines", fragment, len(lines))
+		}
+
+		lines = lines[start-1 : end]
+	}
+
+	from, err := compileAttributeExpression(marker, "from")
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := compileAttributeExpression(marker, "to")
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := marker.Attribute("bounds", "inclusive")
+	if bounds != "inclusive" && bounds != "exclusive" {
+		return nil, fmt.Errorf("invalid bounds '%s', expected 'inclusive' or 'exclusive'", bounds)
+	}
+
+	start := 0
+	if from != nil {
+		start = indexOfMatchingLine(lines, from, 0)
+		if start < 0 {
+			return nil, fmt.Errorf("no line matching from='%s' found", from)
+		}
+	}
+
+	end := len(lines) - 1
+	if to != nil {
+		searchStart := start
+		if from != nil {
+			searchStart = start + 1
+		}
+
+		end = indexOfMatchingLine(lines, to, searchStart)
+		if end < 0 {
+			return nil, fmt.Errorf("no line matching to='%s' found", to)
+		}
+	}
+
+	if bounds == "exclusive" {
+		if from != nil {
+			start++
+		}
+		if to != nil {
+			end--
+		}
+	}
+
+	if start > end {
+		return []string{}, nil
+	}
+
+	return lines[start : end+1], nil
+}
+
+func parseLineRange(fragment string) (int, int, error) {
+	match := lineRangeExpression.FindStringSubmatch(fragment)
+	if match == nil {
+		return 0, 0, fmt.Errorf("invalid line range '%s', expected format is 'L10' or 'L10-L42'", fragment)
+	}
+
+	start, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(match[2]) == 0 {
+		return start, start, nil
+	}
+
+	end, err := strconv.Atoi(match[2])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return start, end, nil
+}
+
+func compileAttributeExpression(marker *SnippetMarker, name string) (*regexp.Regexp, error) {
+	value, ok := marker.Attributes[name]
+	if !ok {
+		return nil, nil
+	}
+
+	expression, err := regexp.Compile(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression for attribute '%s': %s", name, err)
+	}
+
+	return expression, nil
+}
+
+func indexOfMatchingLine(lines []string, expression *regexp.Regexp, start int) int {
+	for index := start; index < len(lines); index++ {
+		if expression.MatchString(lines[index]) {
+			return index
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+var extractTestLines = []string{"package main", "", "import \"fmt\"", "", "func main() {", "\tfmt.Println(\"main\")", "}", "", "func other() {", "}"}
+
+func TestSplitFileId(t *testing.T) {
+	file, fragment := splitFileId("src/main.go#L10-L42")
+	assert.Equal(t, "src/main.go", file)
+	assert.Equal(t, "L10-L42", fragment)
+
+	file, fragment = splitFileId("main.go")
+	assert.Equal(t, "main.go", file)
+	assert.Equal(t, "", fragment)
+}
+
+func TestExtractLinesRange(t *testing.T) {
+	lines, err := extractLines(extractTestLines, "L5-L7", ParseMarker("insertFile[main.go#L5-L7]"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"func main() {", "\tfmt.Println(\"main\")", "}"}, lines)
+
+	lines, err = extractLines(extractTestLines, "L5-7", ParseMarker("insertFile[main.go#L5-7]"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(lines))
+}
+
+func TestExtractLinesSingleLine(t *testing.T) {
+	lines, err := extractLines(extractTestLines, "L3", ParseMarker("insertFile[main.go#L3]"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"import \"fmt\""}, lines)
+}
+
+func TestExtractLinesRangeOutOfBounds(t *testing.T) {
+	_, err := extractLines(extractTestLines, "L5-L11", ParseMarker("insertFile[main.go#L5-L11]"))
+	assert.EqualError(t, err, "line range 'L5-L11' is out of bounds, file has 10 lines")
+
+	_, err = extractLines(extractTestLines, "L7-L5", ParseMarker("insertFile[main.go#L7-L5]"))
+	assert.Error(t, err)
+}
+
+func TestExtractLinesInvalidRange(t *testing.T) {
+	_, err := extractLines(extractTestLines, "yolo", ParseMarker("insertFile[main.go#yolo]"))
+	assert.EqualError(t, err, "invalid line range 'yolo', expected format is 'L10' or 'L10-L42'")
+}
+
+func TestExtractLinesFromTo(t *testing.T) {
+	lines, err := extractLines(extractTestLines, "", ParseMarker(`insertFile[main.go from="func main" to="^}"]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"func main() {", "\tfmt.Println(\"main\")", "}"}, lines)
+}
+
+func TestExtractLinesFrom(t *testing.T) {
+	lines, err := extractLines(extractTestLines, "", ParseMarker(`insertFile[main.go from="func other"]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"func other() {", "}"}, lines)
+}
+
+func TestExtractLinesTo(t *testing.T) {
+	lines, err := extractLines(extractTestLines, "", ParseMarker(`insertFile[main.go to="^import"]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"package main", "", "import \"fmt\""}, lines)
+}
+
+func TestExtractLinesRangeAndFromTo(t *testing.T) {
+	lines, err := extractLines(extractTestLines, "L6-L10", ParseMarker(`insertFile[main.go#L6-L10 from="^func" to="^}"]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"func other() {", "}"}, lines)
+}
+
+func TestExtractLinesFromNotFound(t *testing.T) {
+	_, err := extractLines(extractTestLines, "", ParseMarker(`insertFile[main.go from="func yolo"]`))
+	assert.EqualError(t, err, "no line matching from='func yolo' found")
+}
+
+func TestExtractLinesInvalidExpression(t *testing.T) {
+	_, err := extractLines(extractTestLines, "", ParseMarker(`insertFile[main.go from="("]`))
+	assert.Error(t, err)
+}
+
+func TestExtractLinesExclusiveBounds(t *testing.T) {
+	lines, err := extractLines(extractTestLines, "", ParseMarker(`insertFile[main.go from="func main" to="^}" bounds=exclusive]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"\tfmt.Println(\"main\")"}, lines)
+}
+
+func TestExtractLinesExclusiveBoundsEmpty(t *testing.T) {
+	lines, err := extractLines(extractTestLines, "", ParseMarker(`insertFile[main.go from="func other" to="^}" bounds=exclusive]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, lines)
+}
+
+func TestExtractLinesInvalidBounds(t *testing.T) {
+	_, err := extractLines(extractTestLines, "", ParseMarker(`insertFile[main.go from="func other" bounds=yolo]`))
+	assert.EqualError(t, err, "invalid bounds 'yolo', expected 'inclusive' or 'exclusive'")
+}
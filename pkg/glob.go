@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// matchPattern reports whether file matches the glob pattern. Patterns without a '/' are matched against the
+// base name of the file, e.g. '*.md'. Patterns containing a '/' are matched against the trailing path segments
+// of the file, where '**' matches any number of segments, e.g. 'docs/**/*.markdown'. Matching is case-insensitive.
+func matchPattern(pattern string, file string) bool {
+	pattern = strings.ToLower(filepath.ToSlash(pattern))
+	file = strings.ToLower(filepath.ToSlash(path.Clean(filepath.ToSlash(file))))
+
+	if !strings.Contains(pattern, "/") {
+		matched, err := path.Match(pattern, path.Base(file))
+		return err == nil && matched
+	}
+
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	fileSegments := strings.Split(strings.Trim(file, "/"), "/")
+
+	for start := range fileSegments {
+		if matchSegments(patternSegments, fileSegments[start:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchSegments(patternSegments []string, fileSegments []string) bool {
+	if len(patternSegments) == 0 {
+		return len(fileSegments) == 0
+	}
+
+	if patternSegments[0] == "**" {
+		for skip := 0; skip <= len(fileSegments); skip++ {
+			if matchSegments(patternSegments[1:], fileSegments[skip:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(fileSegments) == 0 {
+		return false
+	}
+
+	matched, err := path.Match(patternSegments[0], fileSegments[0])
+	if err != nil || !matched {
+		return false
+	}
+
+	return matchSegments(patternSegments[1:], fileSegments[1:])
+}
+
+func matchAnyPattern(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, file) {
+			return true
+		}
+	}
+
+	return false
+}
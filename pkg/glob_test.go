@@ -0,0 +1,35 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+func TestMatchPatternExtension(t *testing.T) {
+	assert.True(t, matchPattern("*.md", "README.md"))
+	assert.True(t, matchPattern("*.md", "docs/README.MD"))
+	assert.True(t, matchPattern("*.md", "./docs/README.md"))
+	assert.False(t, matchPattern("*.md", "notes.rmd"))
+	assert.False(t, matchPattern("*.md", "md"))
+	assert.False(t, matchPattern("*.cmd", "cmd"))
+	assert.False(t, matchPattern("*.md", "README.md.bak"))
+}
+
+func TestMatchPatternPath(t *testing.T) {
+	assert.True(t, matchPattern("docs/*.markdown", "docs/index.markdown"))
+	assert.True(t, matchPattern("docs/*.markdown", "/project/docs/index.markdown"))
+	assert.False(t, matchPattern("docs/*.markdown", "docs/api/index.markdown"))
+	assert.False(t, matchPattern("docs/*.markdown", "index.markdown"))
+}
+
+func TestMatchPatternDoubleStar(t *testing.T) {
+	assert.True(t, matchPattern("docs/**/*.markdown", "docs/index.markdown"))
+	assert.True(t, matchPattern("docs/**/*.markdown", "docs/api/v1/index.markdown"))
+	assert.True(t, matchPattern("docs/**/*.markdown", "/project/docs/api/index.markdown"))
+	assert.False(t, matchPattern("docs/**/*.markdown", "src/api/index.markdown"))
+	assert.False(t, matchPattern("docs/**/*.markdown", "docs/api/index.md"))
+}
+
+func TestMatchPatternInvalid(t *testing.T) {
+	assert.False(t, matchPattern("[", "["))
+}
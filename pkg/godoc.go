@@ -0,0 +1,291 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/doc/comment"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var goDocFormats = []string{"text", "markdown", "html"}
+
+// isGoPackageFile reports whether file is a non-test Go file located in a directory ending with dir
+func isGoPackageFile(file string, dir string) bool {
+	if !strings.HasSuffix(file, ".go") || strings.HasSuffix(file, "_test.go") {
+		return false
+	}
+
+	fileDir := filepath.ToSlash(filepath.Dir(file))
+	dir = path.Clean(filepath.ToSlash(dir))
+
+	return fileDir == dir || strings.HasSuffix(fileDir, "/"+strings.TrimPrefix(dir, "./"))
+}
+
+// parseGoPackage parses all Go files of the package located in dir, the returned file set contains the parsed files
+func parseGoPackage(documents []ParsedDocument, dir string) (*token.FileSet, *doc.Package, error) {
+	fileSet := token.NewFileSet()
+	var files []*ast.File
+
+	for _, document := range documents {
+		if !isGoPackageFile(document.File, dir) {
+			continue
+		}
+
+		file, err := parser.ParseFile(fileSet, document.File, strings.Join(getContentForFile(documents, document.File), "\n"), parser.ParseComments)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(files) > 0 && files[0].Name.Name != file.Name.Name {
+			continue
+		}
+
+		files = append(files, file)
+	}
+
+	if len(files) == 0 {
+		return nil, nil, fmt.Errorf("no Go files found for package '%s'", dir)
+	}
+
+	goPackage, err := doc.NewFromFiles(fileSet, files, path.Base(path.Clean(filepath.ToSlash(dir))))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return fileSet, goPackage, nil
+}
+
+// findGoDoc returns the doc comment of the package or of the function 'Func', type 'Type' or method 'Type.Method'
+func findGoDoc(goPackage *doc.Package, symbol string) (string, error) {
+	if len(symbol) == 0 {
+		return goPackage.Doc, nil
+	}
+
+	receiver, name := "", symbol
+	if index := strings.Index(symbol, "."); index >= 0 {
+		receiver, name = symbol[:index], symbol[index+1:]
+	}
+
+	for _, function := range goPackage.Funcs {
+		if len(receiver) == 0 && function.Name == name {
+			return function.Doc, nil
+		}
+	}
+
+	for _, typ := range goPackage.Types {
+		if len(receiver) == 0 && typ.Name == name {
+			return typ.Doc, nil
+		}
+
+		for _, function := range append(typ.Funcs, typ.Methods...) {
+			if (typ.Name == receiver || len(receiver) == 0) && function.Name == name {
+				return function.Doc, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("symbol '%s' not found", symbol)
+}
+
+func goDocPrinter(goPackage *doc.Package) *comment.Printer {
+	printer := goPackage.Printer()
+	printer.DocLinkURL = func(link *comment.DocLink) string {
+		if len(link.ImportPath) == 0 {
+			return ""
+		}
+		return link.DefaultURL("https://pkg.go.dev")
+	}
+
+	return printer
+}
+
+// goDocFormatForFile returns the format doc comments are rendered in when inserted into file
+func goDocFormatForFile(file string) string {
+	if matchAnyPattern([]string{"*.md", "*.markdown", "*.mdx"}, file) {
+		return "markdown"
+	}
+
+	if matchAnyPattern([]string{"*.html", "*.htm"}, file) {
+		return "html"
+	}
+
+	return "text"
+}
+
+func renderGoDoc(goPackage *doc.Package, text string, format string) []string {
+	printer := goDocPrinter(goPackage)
+	parsed := goPackage.Parser().Parse(text)
+
+	var rendered []byte
+	switch format {
+	case "markdown":
+		rendered = printer.Markdown(parsed)
+	case "html":
+		rendered = printer.HTML(parsed)
+	default:
+		rendered = printer.Text(parsed)
+	}
+
+	return strings.Split(strings.TrimSuffix(string(rendered), "\n"), "\n")
+}
+
+// extractGoDoc returns the rendered doc comment for a package 'path/to/package' or a symbol inside of a package
+// 'path/to/package#Type', the format is derived from the target file unless set via the 'format' attribute
+func extractGoDoc(documents []ParsedDocument, marker *SnippetMarker, target string) ([]string, error) {
+	dir, symbol := splitFileId(marker.Id)
+
+	format := marker.Attribute("format", goDocFormatForFile(target))
+	if !contains(goDocFormats, format) {
+		return nil, fmt.Errorf("unknown format '%s', available formats are: %s", format, strings.Join(goDocFormats, ", "))
+	}
+
+	_, goPackage, err := parseGoPackage(documents, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := findGoDoc(goPackage, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderGoDoc(goPackage, text, format), nil
+}
+
+// extractGoApi returns a 'go doc' like summary of the exported API of a package, listing the signature and the
+// first sentence of the doc comment for every exported const, var, function, type and method. The returned file is
+// one of the files of the package.
+func extractGoApi(documents []ParsedDocument, marker *SnippetMarker) ([]string, string, error) {
+	fileSet, goPackage, err := parseGoPackage(documents, marker.Id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var file string
+	fileSet.Iterate(func(f *token.File) bool {
+		file = f.Name()
+		return false
+	})
+
+	var lines []string
+	addEntry := func(signature string, text string) {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.Split(signature, "\n")...)
+
+		synopsis := goPackage.Synopsis(text)
+		if len(synopsis) > 0 {
+			lines = append(lines, "    "+synopsis)
+		}
+	}
+
+	addValues := func(values []*doc.Value) {
+		for _, value := range values {
+			addEntry(formatGoNode(fileSet, value.Decl), value.Doc)
+		}
+	}
+
+	addFunctions := func(functions []*doc.Func) {
+		for _, function := range functions {
+			addEntry(formatGoFuncSignature(fileSet, function.Decl), function.Doc)
+		}
+	}
+
+	addValues(goPackage.Consts)
+	addValues(goPackage.Vars)
+	addFunctions(goPackage.Funcs)
+
+	for _, typ := range goPackage.Types {
+		addEntry(formatGoTypeSignature(fileSet, typ.Decl), typ.Doc)
+		addValues(typ.Consts)
+		addValues(typ.Vars)
+		addFunctions(typ.Funcs)
+		addFunctions(typ.Methods)
+	}
+
+	return lines, file, nil
+}
+
+func formatGoNode(fileSet *token.FileSet, node any) string {
+	var buffer bytes.Buffer
+	if err := printer.Fprint(&buffer, fileSet, node); err != nil {
+		return ""
+	}
+
+	return buffer.String()
+}
+
+func formatGoFuncSignature(fileSet *token.FileSet, decl *ast.FuncDecl) string {
+	signature := *decl
+	signature.Body = nil
+	signature.Doc = nil
+
+	return formatGoNode(fileSet, &signature)
+}
+
+// formatGoTypeSignature formats a type declaration, abbreviating the fields of structs and interfaces
+func formatGoTypeSignature(fileSet *token.FileSet, decl *ast.GenDecl) string {
+	var signatures []string
+
+	for _, spec := range decl.Specs {
+		typeSpec, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+
+		typeSpec = &ast.TypeSpec{Name: typeSpec.Name, TypeParams: typeSpec.TypeParams, Assign: typeSpec.Assign, Type: typeSpec.Type}
+		switch typ := typeSpec.Type.(type) {
+		case *ast.StructType:
+			signatures = append(signatures, fmt.Sprintf("type %s struct{ ... }", formatGoTypeName(fileSet, typeSpec)))
+			continue
+		case *ast.InterfaceType:
+			if typ.Methods != nil && len(typ.Methods.List) > 0 {
+				signatures = append(signatures, fmt.Sprintf("type %s interface{ ... }", formatGoTypeName(fileSet, typeSpec)))
+				continue
+			}
+		}
+
+		signatures = append(signatures, "type "+formatGoNode(fileSet, typeSpec))
+	}
+
+	return strings.Join(signatures, "\n")
+}
+
+func formatGoTypeName(fileSet *token.FileSet, typeSpec *ast.TypeSpec) string {
+	if typeSpec.TypeParams == nil {
+		return typeSpec.Name.Name
+	}
+
+	return typeSpec.Name.Name + formatGoTypeParams(fileSet, typeSpec.TypeParams)
+}
+
+func formatGoTypeParams(fileSet *token.FileSet, typeParams *ast.FieldList) string {
+	var params []string
+	for _, field := range typeParams.List {
+		var names []string
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		params = append(params, strings.Join(names, ", ")+" "+formatGoNode(fileSet, field.Type))
+	}
+
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,158 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+var goDocSource = `// Package server provides a [Server] for serving things.
+//
+// Servers are created with [NewServer].
+package server
+
+// DefaultPort is the default port. It is used if no port is set.
+const DefaultPort = 8080
+
+// Server serves things. It is safe for concurrent use.
+type Server struct {
+	Port int
+}
+
+// Handler handles things.
+type Handler interface {
+	Handle() error
+}
+
+// NewServer creates a new server.
+func NewServer() *Server {
+	return &Server{Port: DefaultPort}
+}
+
+// Start starts the server. It blocks until the server is stopped.
+func (s *Server) Start() error {
+	return nil
+}
+
+func (s *Server) stop() {
+}
+
+// Version returns the version.
+func Version() string {
+	return "1.0.0"
+}
+`
+
+func goDocTestDocuments(t *testing.T) []ParsedDocument {
+	document1, err := ParseDocument(Document{File: "project/pkg/server/server.go", Content: goDocSource})
+	assert.NoError(t, err)
+
+	document2, err := ParseDocument(Document{File: "project/pkg/server/server_test.go", Content: "package server\n\n// TestYolo is a test\nfunc TestYolo() {}"})
+	assert.NoError(t, err)
+
+	document3, err := ParseDocument(Document{File: "project/pkg/other/other.go", Content: "// Package other is something else\npackage other"})
+	assert.NoError(t, err)
+
+	return []ParsedDocument{document1, document2, document3}
+}
+
+func TestIsGoPackageFile(t *testing.T) {
+	assert.True(t, isGoPackageFile("project/pkg/server/server.go", "pkg/server"))
+	assert.True(t, isGoPackageFile("project/pkg/server/server.go", "./pkg/server/"))
+	assert.False(t, isGoPackageFile("project/pkg/server/server_test.go", "pkg/server"))
+	assert.False(t, isGoPackageFile("project/pkg/myserver/server.go", "server"))
+	assert.False(t, isGoPackageFile("project/pkg/server/README.md", "pkg/server"))
+}
+
+func TestExtractGoDocPackage(t *testing.T) {
+	lines, err := extractGoDoc(goDocTestDocuments(t), ParseMarker("insertGoDoc[pkg/server]"), "README.md")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Package server provides a Server for serving things.", "", "Servers are created with NewServer."}, lines)
+}
+
+func TestExtractGoDocText(t *testing.T) {
+	lines, err := extractGoDoc(goDocTestDocuments(t), ParseMarker("insertGoDoc[pkg/server#Server.Start]"), "README.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Start starts the server. It blocks until the server is stopped."}, lines)
+}
+
+func TestExtractGoDocSymbols(t *testing.T) {
+	documents := goDocTestDocuments(t)
+
+	lines, err := extractGoDoc(documents, ParseMarker("insertGoDoc[pkg/server#Server]"), "README.md")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Server serves things. It is safe for concurrent use."}, lines)
+
+	lines, err = extractGoDoc(documents, ParseMarker("insertGoDoc[pkg/server#NewServer]"), "README.md")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"NewServer creates a new server."}, lines)
+
+	lines, err = extractGoDoc(documents, ParseMarker("insertGoDoc[pkg/server#Version]"), "README.md")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Version returns the version."}, lines)
+}
+
+func TestExtractGoDocHtml(t *testing.T) {
+	lines, err := extractGoDoc(goDocTestDocuments(t), ParseMarker("insertGoDoc[pkg/server#Version]"), "index.html")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"<p>Version returns the version."}, lines)
+}
+
+func TestExtractGoDocErrors(t *testing.T) {
+	documents := goDocTestDocuments(t)
+
+	_, err := extractGoDoc(documents, ParseMarker("insertGoDoc[pkg/server#Yolo]"), "README.md")
+	assert.EqualError(t, err, "symbol 'Yolo' not found")
+
+	_, err = extractGoDoc(documents, ParseMarker("insertGoDoc[pkg/yolo]"), "README.md")
+	assert.EqualError(t, err, "no Go files found for package 'pkg/yolo'")
+
+	_, err = extractGoDoc(documents, ParseMarker("insertGoDoc[pkg/server format=yolo]"), "README.md")
+	assert.EqualError(t, err, "unknown format 'yolo', available formats are: text, markdown, html")
+}
+
+func TestExtractGoApi(t *testing.T) {
+	lines, file, err := extractGoApi(goDocTestDocuments(t), ParseMarker("insertGoApi[pkg/server]"))
+	assert.NoError(t, err)
+	assert.Equal(t, "project/pkg/server/server.go", file)
+	assert.Equal(t, []string{
+		"const DefaultPort = 8080",
+		"    DefaultPort is the default port.",
+		"",
+		"func Version() string",
+		"    Version returns the version.",
+		"",
+		"type Handler interface{ ... }",
+		"    Handler handles things.",
+		"",
+		"type Server struct{ ... }",
+		"    Server serves things.",
+		"",
+		"func NewServer() *Server",
+		"    NewServer creates a new server.",
+		"",
+		"func (s *Server) Start() error",
+		"    Start starts the server.",
+	}, lines)
+}
+
+func TestReplaceGoDoc(t *testing.T) {
+
+	target := `<!-- insertGoDoc[pkg/server] -->
+<!-- /insertGoDoc -->`
+
+	targetReplaced := `<!-- insertGoDoc[pkg/server] -->
+Package server provides a Server for serving things.
+
+Servers are created with NewServer.
+<!-- /insertGoDoc -->`
+
+	document, err := ParseDocument(Document{File: "project/README.md", Content: target})
+	assert.NoError(t, err)
+
+	documents := append(goDocTestDocuments(t), document)
+	assert.Equal(t, 0, len(ValidateDocuments(documents)))
+
+	replacedDocuments, err := ReplaceSnippets(documents, "")
+	assert.NoError(t, err)
+	assert.Equal(t, targetReplaced, replacedDocuments[3].Content)
+}
@@ -0,0 +1,123 @@
+package pkg
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var exampleOutputExpression = regexp.MustCompile(`^\s*//\s*(?i:unordered output|output):`)
+
+// isGoTestFile reports whether file is a Go test file located in a directory ending with dir
+func isGoTestFile(file string, dir string) bool {
+	if !strings.HasSuffix(file, "_test.go") {
+		return false
+	}
+
+	fileDir := filepath.ToSlash(filepath.Dir(file))
+	dir = path.Clean(filepath.ToSlash(dir))
+
+	return fileDir == dir || strings.HasSuffix(fileDir, "/"+strings.TrimPrefix(dir, "./"))
+}
+
+// findGoExample returns the example function 'ExampleXxx' from the test files in dir, the 'Example' prefix of the
+// name is optional
+func findGoExample(documents []ParsedDocument, dir string, name string) (*token.FileSet, *doc.Example, string, error) {
+	fileSet := token.NewFileSet()
+	var files []*ast.File
+	var fileNames []string
+
+	for _, document := range documents {
+		if !isGoTestFile(document.File, dir) {
+			continue
+		}
+
+		file, err := parser.ParseFile(fileSet, document.File, strings.Join(getContentForFile(documents, document.File), "\n"), parser.ParseComments)
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		files = append(files, file)
+		fileNames = append(fileNames, document.File)
+	}
+
+	if len(files) == 0 {
+		return nil, nil, "", fmt.Errorf("no Go test files found for package '%s'", dir)
+	}
+
+	exampleName := strings.TrimPrefix(strings.TrimPrefix(name, "Example"), "_")
+
+	for _, example := range doc.Examples(files...) {
+		if example.Name != exampleName {
+			continue
+		}
+
+		file := ""
+		for index, astFile := range files {
+			if astFile.Pos() <= example.Code.Pos() && example.Code.Pos() <= astFile.End() {
+				file = fileNames[index]
+			}
+		}
+
+		return fileSet, example, file, nil
+	}
+
+	return nil, nil, "", fmt.Errorf("example '%s' not found in package '%s'", name, dir)
+}
+
+// exampleCode returns the body of the example function without braces and without the output comment
+func exampleCode(fileSet *token.FileSet, example *doc.Example) []string {
+	code := new(strings.Builder)
+	_ = (&printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}).Fprint(code, fileSet, &printer.CommentedNode{Node: example.Code, Comments: example.Comments})
+
+	lines := strings.Split(code.String(), "\n")
+	if _, ok := example.Code.(*ast.BlockStmt); ok && len(lines) >= 2 {
+		lines = lines[1 : len(lines)-1]
+	}
+
+	for index, line := range lines {
+		if exampleOutputExpression.MatchString(line) {
+			lines = lines[:index]
+			break
+		}
+	}
+
+	for len(lines) > 0 && isBlank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+
+	return removeIndentation(lines)
+}
+
+// extractGoExample returns the code and the expected output of an example function referenced like
+// 'pkg/server#ExampleServer_Start'. Examples without an output comment are refused, because only examples with an
+// output comment are verified by 'go test'.
+func extractGoExample(documents []ParsedDocument, marker *SnippetMarker) ([]string, []string, string, error) {
+	dir, name := splitFileId(marker.Id)
+	if len(name) == 0 {
+		return nil, nil, "", fmt.Errorf("no example specified, expected '%s#ExampleXxx'", dir)
+	}
+
+	fileSet, example, file, err := findGoExample(documents, dir, name)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if len(example.Output) == 0 && !example.EmptyOutput {
+		return nil, nil, "", fmt.Errorf("example '%s' has no '// Output:' comment, only examples with output are verified by 'go test'", name)
+	}
+
+	var output []string
+	if !example.EmptyOutput {
+		output = strings.Split(strings.TrimRight(example.Output, "\n"), "\n")
+	}
+
+	return exampleCode(fileSet, example), output, file, nil
+}
@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+var greeterExampleSource = `package greeter_test
+
+import (
+	"fmt"
+
+	"example.org/greeter"
+)
+
+func ExampleGreeter_Greet() {
+	g := greeter.New("Hello")
+
+	// greet someone
+	fmt.Println(g.Greet("World"))
+	// Output: Hello World
+}
+
+func ExampleNew() {
+	fmt.Println(greeter.New("Hi") != nil)
+	// Output:
+	// true
+	// more
+}
+
+func ExampleGreeter() {
+	greeter.New("Hello")
+}
+`
+
+func goExampleDocumentsTest(t *testing.T) []ParsedDocument {
+	document, err := ParseDocument(Document{File: "pkg/greeter/greeter_test.go", Content: greeterExampleSource})
+	assert.NoError(t, err)
+
+	return []ParsedDocument{document}
+}
+
+func TestExtractGoExample(t *testing.T) {
+	code, output, file, err := extractGoExample(goExampleDocumentsTest(t), ParseMarker("insertGoExample[pkg/greeter#ExampleGreeter_Greet]"))
+	assert.NoError(t, err)
+	assert.Equal(t, "pkg/greeter/greeter_test.go", file)
+	assert.Equal(t, []string{
+		`g := greeter.New("Hello")`,
+		"",
+		"// greet someone",
+		`fmt.Println(g.Greet("World"))`,
+	}, code)
+	assert.Equal(t, []string{"Hello World"}, output)
+
+	_, output, _, err = extractGoExample(goExampleDocumentsTest(t), ParseMarker("insertGoExample[greeter#New]"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"true", "more"}, output)
+}
+
+func TestExtractGoExampleErrors(t *testing.T) {
+	_, _, _, err := extractGoExample(goExampleDocumentsTest(t), ParseMarker("insertGoExample[pkg/greeter#ExampleGreeter]"))
+	assert.EqualError(t, err, "example 'ExampleGreeter' has no '// Output:' comment, only examples with output are verified by 'go test'")
+
+	_, _, _, err = extractGoExample(goExampleDocumentsTest(t), ParseMarker("insertGoExample[pkg/greeter#ExampleMissing]"))
+	assert.EqualError(t, err, "example 'ExampleMissing' not found in package 'pkg/greeter'")
+
+	_, _, _, err = extractGoExample(goExampleDocumentsTest(t), ParseMarker("insertGoExample[pkg/other#ExampleNew]"))
+	assert.EqualError(t, err, "no Go test files found for package 'pkg/other'")
+}
+
+func TestReplaceSnippetsInsertGoExample(t *testing.T) {
+	target, err := ParseDocument(Document{File: "README.md", Content: "<!-- insertGoExample[pkg/greeter#ExampleGreeter_Greet] -->\n<!-- /insertGoExample -->"})
+	assert.NoError(t, err)
+
+	documents, err := ReplaceSnippets(append(goExampleDocumentsTest(t), target), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "<!-- insertGoExample[pkg/greeter#ExampleGreeter_Greet] -->\n```\ng := greeter.New(\"Hello\")\n\n// greet someone\nfmt.Println(g.Greet(\"World\"))\n```\n\n```\nHello World\n```\n\n<!-- /insertGoExample -->", documents[1].Content)
+}
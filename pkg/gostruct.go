@@ -0,0 +1,210 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strings"
+	template2 "text/template"
+)
+
+// goStructField is a single field of a Go struct, as available in custom struct templates
+type goStructField struct {
+	Name    string
+	Type    string
+	Default string
+	Comment string
+	Tags    map[string]string
+}
+
+// goStructTemplateData is available in custom struct templates set via the 'template' attribute
+type goStructTemplateData struct {
+	Name    string
+	Headers []string
+	Rows    [][]string
+	Fields  []goStructField
+}
+
+var goStructColumnHeaders = map[string]string{
+	"field":   "Field",
+	"type":    "Type",
+	"default": "Default",
+	"comment": "Comment",
+	"json":    "JSON",
+	"yaml":    "YAML",
+	"env":     "Env",
+}
+
+var goStructDefaultTags = []string{"json", "yaml", "env"}
+
+func findGoStruct(file string, source string, name string) ([]goStructField, error) {
+	fileSet, parsedFile, err := parseGoFile(file, source)
+	if err != nil {
+		return nil, err
+	}
+
+	var structType *ast.StructType
+	ast.Inspect(parsedFile, func(node ast.Node) bool {
+		if typeSpec, ok := node.(*ast.TypeSpec); ok && typeSpec.Name.Name == name {
+			structType, _ = typeSpec.Type.(*ast.StructType)
+		}
+		return structType == nil
+	})
+
+	if structType == nil {
+		return nil, fmt.Errorf("struct '%s' not found", name)
+	}
+
+	var fields []goStructField
+	for _, field := range structType.Fields.List {
+		tag := reflect.StructTag("")
+		if field.Tag != nil {
+			tag = reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+		}
+
+		comment := strings.TrimSpace(field.Doc.Text() + " " + field.Comment.Text())
+
+		names := field.Names
+		if len(names) == 0 {
+			embedded := strings.TrimPrefix(formatGoNode(fileSet, field.Type), "*")
+			names = []*ast.Ident{ast.NewIdent(embedded[strings.LastIndex(embedded, ".")+1:])}
+		}
+
+		for _, name := range names {
+			if !name.IsExported() {
+				continue
+			}
+
+			tags := map[string]string{}
+			for _, key := range structTagKeys(tag) {
+				value := tag.Get(key)
+				tags[key] = strings.Split(value, ",")[0]
+			}
+
+			fields = append(fields, goStructField{Name: name.Name, Type: formatGoNode(fileSet, field.Type), Default: tag.Get("default"), Comment: comment, Tags: tags})
+		}
+	}
+
+	return fields, nil
+}
+
+// structTagKeys returns the keys of a struct tag like 'json:"name" env:"NAME"'
+func structTagKeys(tag reflect.StructTag) []string {
+	var keys []string
+
+	for _, part := range strings.Fields(string(tag)) {
+		if index := strings.Index(part, ":\""); index > 0 {
+			keys = append(keys, part[:index])
+		}
+	}
+
+	return keys
+}
+
+// defaultGoStructColumns returns the columns used if no columns are set via the 'columns' attribute. Field and type
+// are always shown, the json, yaml and env tags, the default value and the comment only if set for at least one field.
+func defaultGoStructColumns(fields []goStructField) []tableColumn {
+	columns := []tableColumn{{Key: "field", Header: goStructColumnHeaders["field"]}, {Key: "type", Header: goStructColumnHeaders["type"]}}
+
+	hasValue := func(value func(field goStructField) string) bool {
+		for _, field := range fields {
+			if len(value(field)) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, tag := range goStructDefaultTags {
+		tag := tag
+		if hasValue(func(field goStructField) string { return field.Tags[tag] }) {
+			columns = append(columns, tableColumn{Key: tag, Header: goStructColumnHeaders[tag]})
+		}
+	}
+
+	if hasValue(func(field goStructField) string { return field.Default }) {
+		columns = append(columns, tableColumn{Key: "default", Header: goStructColumnHeaders["default"]})
+	}
+
+	if hasValue(func(field goStructField) string { return field.Comment }) {
+		columns = append(columns, tableColumn{Key: "comment", Header: goStructColumnHeaders["comment"]})
+	}
+
+	return columns
+}
+
+func (field goStructField) column(key string) string {
+	switch key {
+	case "field":
+		return field.Name
+	case "type":
+		return field.Type
+	case "default":
+		return field.Default
+	case "comment":
+		return field.Comment
+	}
+
+	return field.Tags[key]
+}
+
+// extractGoStruct renders a table for the struct referenced like 'path/to/file.go#Config'. The columns can be
+// selected and renamed via the 'columns' attribute, e.g. 'columns="field:Name,env,comment"', where every column
+// besides field, type, default and comment refers to a struct tag. A custom text/template can be set via the
+// 'template' attribute.
+func extractGoStruct(documents []ParsedDocument, marker *SnippetMarker) ([]string, error) {
+	file, name := splitFileId(marker.Id)
+	if len(name) == 0 {
+		return nil, fmt.Errorf("no struct specified, expected format is 'file.go#Struct'")
+	}
+
+	document := getDocumentForFile(documents, file)
+	if document == nil {
+		return nil, fmt.Errorf("file '%s' not found", file)
+	}
+
+	fields, err := findGoStruct(document.File, strings.Join(getContentForFile(documents, file), "\n"), name)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := defaultGoStructColumns(fields)
+	if columnsAttribute, ok := marker.Attributes["columns"]; ok {
+		columns = parseTableColumns(columnsAttribute)
+		for index, column := range columns {
+			if header, ok := goStructColumnHeaders[column.Header]; ok {
+				columns[index].Header = header
+			}
+		}
+	}
+
+	data := goStructTemplateData{Name: name, Fields: fields}
+	for _, column := range columns {
+		data.Headers = append(data.Headers, column.Header)
+	}
+	for _, field := range fields {
+		var row []string
+		for _, column := range columns {
+			row = append(row, field.column(column.Key))
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	template, ok := marker.Attributes["template"]
+	if !ok {
+		return renderMarkdownTable(data.Headers, data.Rows, true), nil
+	}
+
+	tmpl, err := template2.New("struct").Funcs(templateFunctions).Parse(strings.ReplaceAll(template, "\\n", "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := new(bytes.Buffer)
+	if err := tmpl.Execute(rendered, data); err != nil {
+		return nil, err
+	}
+
+	return strings.Split(strings.TrimSuffix(rendered.String(), "\n"), "\n"), nil
+}
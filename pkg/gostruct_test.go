@@ -0,0 +1,111 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+var goStructSource = `package config
+
+import "time"
+
+type Embedded struct{}
+
+// Config configures the server
+type Config struct {
+	// Port to listen on
+	Port int ` + "`json:\"port,omitempty\" env:\"PORT\" default:\"8080\"`" + `
+	Host string ` + "`json:\"host\" yaml:\"host\" env:\"HOST\"`" + ` // Host to bind to
+	Timeout time.Duration
+	internal string
+	*Embedded
+}
+`
+
+func goStructTestDocuments(t *testing.T) []ParsedDocument {
+	document, err := ParseDocument(Document{File: "pkg/config.go", Content: goStructSource})
+	assert.NoError(t, err)
+
+	return []ParsedDocument{document}
+}
+
+func TestFindGoStruct(t *testing.T) {
+	fields, err := findGoStruct("config.go", goStructSource, "Config")
+	assert.NoError(t, err)
+	assert.Equal(t, []goStructField{
+		{Name: "Port", Type: "int", Default: "8080", Comment: "Port to listen on", Tags: map[string]string{"json": "port", "env": "PORT", "default": "8080"}},
+		{Name: "Host", Type: "string", Comment: "Host to bind to", Tags: map[string]string{"json": "host", "yaml": "host", "env": "HOST"}},
+		{Name: "Timeout", Type: "time.Duration", Tags: map[string]string{}},
+		{Name: "Embedded", Type: "*Embedded", Tags: map[string]string{}},
+	}, fields)
+}
+
+func TestExtractGoStruct(t *testing.T) {
+	lines, err := extractGoStruct(goStructTestDocuments(t), ParseMarker("insertGoStruct[pkg/config.go#Config]"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"| Field | Type | JSON | YAML | Env | Default | Comment |",
+		"|---|---|---|---|---|---|---|",
+		"| Port | int | port |  | PORT | 8080 | Port to listen on |",
+		"| Host | string | host | host | HOST |  | Host to bind to |",
+		"| Timeout | time.Duration |  |  |  |  |  |",
+		"| Embedded | *Embedded |  |  |  |  |  |",
+	}, lines)
+}
+
+func TestExtractGoStructColumns(t *testing.T) {
+	lines, err := extractGoStruct(goStructTestDocuments(t), ParseMarker(`insertGoStruct[pkg/config.go#Config columns="env:Variable,default,comment:Description"]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"| Variable | Default | Description |",
+		"|---|---|---|",
+		"| PORT | 8080 | Port to listen on |",
+		"| HOST |  | Host to bind to |",
+		"|  |  |  |",
+		"|  |  |  |",
+	}, lines)
+}
+
+func TestExtractGoStructTemplate(t *testing.T) {
+	lines, err := extractGoStruct(goStructTestDocuments(t), ParseMarker(`insertGoStruct[pkg/config.go#Config template="{{range .Fields}}{{if .Tags.env}}* {{.Tags.env}}: {{.Comment}}\n{{end}}{{end}}"]`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"* PORT: Port to listen on", "* HOST: Host to bind to"}, lines)
+}
+
+func TestExtractGoStructErrors(t *testing.T) {
+	documents := goStructTestDocuments(t)
+
+	_, err := extractGoStruct(documents, ParseMarker("insertGoStruct[pkg/config.go#Yolo]"))
+	assert.EqualError(t, err, "struct 'Yolo' not found")
+
+	_, err = extractGoStruct(documents, ParseMarker("insertGoStruct[pkg/config.go]"))
+	assert.EqualError(t, err, "no struct specified, expected format is 'file.go#Struct'")
+
+	_, err = extractGoStruct(documents, ParseMarker(`insertGoStruct[pkg/config.go#Config template="{{.Yolo}}"]`))
+	assert.Error(t, err)
+}
+
+func TestReplaceGoStruct(t *testing.T) {
+
+	target := `<!-- insertGoStruct[config.go#Config columns="field,env"] -->
+<!-- /insertGoStruct -->`
+
+	targetReplaced := `<!-- insertGoStruct[config.go#Config columns="field,env"] -->
+| Field | Env |
+|---|---|
+| Port | PORT |
+| Host | HOST |
+| Timeout |  |
+| Embedded |  |
+<!-- /insertGoStruct -->`
+
+	document, err := ParseDocument(Document{File: "README.md", Content: target})
+	assert.NoError(t, err)
+
+	documents := append(goStructTestDocuments(t), document)
+	assert.Equal(t, 0, len(ValidateDocuments(documents)))
+
+	replacedDocuments, err := ReplaceSnippets(documents, "")
+	assert.NoError(t, err)
+	assert.Equal(t, targetReplaced, replacedDocuments[1].Content)
+}
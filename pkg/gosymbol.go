@@ -0,0 +1,245 @@
+package pkg
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// goSymbol is a top level declaration inside of a parsed Go file
+type goSymbol struct {
+	fileSet *token.FileSet
+	source  string
+	doc     *ast.CommentGroup
+	node    ast.Node
+	// parenthesized is set for type specs declared inside of a 'type ( ... )' block
+	parenthesized bool
+}
+
+func parseGoFile(file string, source string) (*token.FileSet, *ast.File, error) {
+	fileSet := token.NewFileSet()
+	parsedFile, err := parser.ParseFile(fileSet, file, source, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return fileSet, parsedFile, nil
+}
+
+// findGoSymbol looks up a function 'Func', a method 'Type.Method', a type 'Type' or a const or var block
+// containing 'Name' inside of a Go file
+func findGoSymbol(file string, source string, symbol string) (*goSymbol, error) {
+	fileSet, parsedFile, err := parseGoFile(file, source)
+	if err != nil {
+		return nil, err
+	}
+
+	receiver, name := "", symbol
+	if index := strings.Index(symbol, "."); index >= 0 {
+		receiver, name = symbol[:index], symbol[index+1:]
+	}
+
+	for _, decl := range parsedFile.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Name.Name == name && receiverTypeName(decl) == receiver {
+				return &goSymbol{fileSet: fileSet, source: source, doc: decl.Doc, node: decl}, nil
+			}
+
+		case *ast.GenDecl:
+			if len(receiver) > 0 {
+				continue
+			}
+
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.Name != name {
+						continue
+					}
+
+					if decl.Lparen.IsValid() {
+						return &goSymbol{fileSet: fileSet, source: source, doc: spec.Doc, node: spec, parenthesized: true}, nil
+					}
+					return &goSymbol{fileSet: fileSet, source: source, doc: decl.Doc, node: decl}, nil
+
+				case *ast.ValueSpec:
+					for _, specName := range spec.Names {
+						if specName.Name == name {
+							return &goSymbol{fileSet: fileSet, source: source, doc: decl.Doc, node: decl}, nil
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("symbol '%s' not found", symbol)
+}
+
+func receiverTypeName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+
+	expr := decl.Recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+func (symbol *goSymbol) offset(pos token.Pos) int {
+	return symbol.fileSet.Position(pos).Offset
+}
+
+// lineStart returns the offset of the beginning of the line containing pos
+func (symbol *goSymbol) lineStart(pos token.Pos) int {
+	return strings.LastIndex(symbol.source[:symbol.offset(pos)], "\n") + 1
+}
+
+func (symbol *goSymbol) lines(start int, end int) []string {
+	return strings.Split(symbol.source[start:end], "\n")
+}
+
+// declaration returns the complete declaration, optionally including its doc comment
+func (symbol *goSymbol) declaration(withDoc bool) []string {
+	start := symbol.node.Pos()
+	if withDoc && symbol.doc != nil {
+		start = symbol.doc.Pos()
+	}
+
+	lines := symbol.lines(symbol.lineStart(start), symbol.offset(symbol.node.End()))
+
+	if symbol.parenthesized {
+		lines = removeIndentation(lines)
+		for index, line := range lines {
+			if !strings.HasPrefix(line, "//") {
+				lines[index] = "type " + line
+				break
+			}
+		}
+	}
+
+	return lines
+}
+
+// signature returns the signature of a function or method without its body
+func (symbol *goSymbol) signature() ([]string, error) {
+	decl, ok := symbol.node.(*ast.FuncDecl)
+	if !ok {
+		return nil, fmt.Errorf("signature-only is only supported for functions and methods")
+	}
+
+	end := decl.End()
+	if decl.Body != nil {
+		end = decl.Body.Lbrace
+	}
+
+	return strings.Split(strings.TrimSpace(symbol.source[symbol.offset(decl.Pos()):symbol.offset(end)]), "\n"), nil
+}
+
+// body returns the content between the braces of a function, method, struct or interface
+func (symbol *goSymbol) body() ([]string, error) {
+	var lbrace, rbrace token.Pos
+
+	switch node := symbol.node.(type) {
+	case *ast.FuncDecl:
+		if node.Body == nil {
+			return nil, fmt.Errorf("function '%s' has no body", node.Name.Name)
+		}
+		lbrace, rbrace = node.Body.Lbrace, node.Body.Rbrace
+
+	case *ast.GenDecl:
+		if len(node.Specs) != 1 {
+			return nil, fmt.Errorf("body-only is only supported for functions, methods, structs and interfaces")
+		}
+		return (&goSymbol{fileSet: symbol.fileSet, source: symbol.source, node: node.Specs[0]}).body()
+
+	case *ast.TypeSpec:
+		switch typ := node.Type.(type) {
+		case *ast.StructType:
+			lbrace, rbrace = typ.Fields.Opening, typ.Fields.Closing
+		case *ast.InterfaceType:
+			lbrace, rbrace = typ.Methods.Opening, typ.Methods.Closing
+		default:
+			return nil, fmt.Errorf("body-only is only supported for functions, methods, structs and interfaces")
+		}
+
+	default:
+		return nil, fmt.Errorf("body-only is only supported for functions, methods, structs and interfaces")
+	}
+
+	lines := symbol.lines(symbol.offset(lbrace)+1, symbol.offset(rbrace))
+	if len(lines) > 1 && isBlank(lines[0]) {
+		lines = lines[1:]
+	}
+	if len(lines) > 1 && isBlank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 1 {
+		lines[0] = strings.TrimSpace(lines[0])
+	}
+
+	return lines, nil
+}
+
+// extractGoSymbol returns the source of a Go symbol referenced like 'path/to/file.go#Type.Method', the marker
+// attributes 'body-only', 'signature-only' and 'doc=false' control which part of the declaration is returned
+func extractGoSymbol(documents []ParsedDocument, marker *SnippetMarker) ([]string, string, error) {
+	file, symbolName := splitFileId(marker.Id)
+	if len(symbolName) == 0 {
+		return nil, "", fmt.Errorf("no symbol specified, expected format is 'file.go#Symbol'")
+	}
+
+	document := getDocumentForFile(documents, file)
+	if document == nil {
+		return nil, "", fmt.Errorf("file '%s' not found", file)
+	}
+
+	symbol, err := findGoSymbol(document.File, strings.Join(getContentForFile(documents, file), "\n"), symbolName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	bodyOnly, err := marker.BoolAttribute("body-only", false)
+	if err != nil {
+		return nil, "", err
+	}
+
+	signatureOnly, err := marker.BoolAttribute("signature-only", false)
+	if err != nil {
+		return nil, "", err
+	}
+
+	withDoc, err := marker.BoolAttribute("doc", true)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var lines []string
+	switch {
+	case bodyOnly && signatureOnly:
+		return nil, "", fmt.Errorf("body-only and signature-only can not be combined")
+	case bodyOnly:
+		lines, err = symbol.body()
+	case signatureOnly:
+		lines, err = symbol.signature()
+	default:
+		lines = symbol.declaration(withDoc)
+	}
+
+	return lines, file, err
+}
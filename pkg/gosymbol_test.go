@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+var goSymbolSource = `package example
+
+import "fmt"
+
+const (
+	// DefaultPort is the default port
+	DefaultPort = 8080
+	DefaultHost = "localhost"
+)
+
+// Server serves things
+type Server struct {
+	Port int
+	Host string
+}
+
+type (
+	// Handler handles things
+	Handler interface {
+		Handle() error
+	}
+	Id string
+)
+
+// NewServer creates a new server
+func NewServer() *Server {
+	return &Server{Port: DefaultPort}
+}
+
+// Start starts the server
+func (s *Server) Start() error {
+	fmt.Println("starting")
+	return nil
+}
+
+func (s Server) Address() string { return fmt.Sprintf("%s:%d", s.Host, s.Port) }
+`
+
+func extractGoSymbolTest(t *testing.T, marker string) []string {
+	document, err := ParseDocument(Document{File: "src/example.go", Content: goSymbolSource})
+	assert.NoError(t, err)
+
+	lines, file, err := extractGoSymbol([]ParsedDocument{document}, ParseMarker(marker))
+	assert.NoError(t, err)
+	assert.Equal(t, "example.go", file)
+
+	return lines
+}
+
+func TestExtractGoSymbolFunction(t *testing.T) {
+	assert.Equal(t, []string{"// NewServer creates a new server", "func NewServer() *Server {", "\treturn &Server{Port: DefaultPort}", "}"}, extractGoSymbolTest(t, "insertGoSymbol[example.go#NewServer]"))
+}
+
+func TestExtractGoSymbolFunctionWithoutDoc(t *testing.T) {
+	assert.Equal(t, []string{"func NewServer() *Server {", "\treturn &Server{Port: DefaultPort}", "}"}, extractGoSymbolTest(t, "insertGoSymbol[example.go#NewServer doc=false]"))
+}
+
+func TestExtractGoSymbolMethod(t *testing.T) {
+	assert.Equal(t, []string{"// Start starts the server", "func (s *Server) Start() error {", "\tfmt.Println(\"starting\")", "\treturn nil", "}"}, extractGoSymbolTest(t, "insertGoSymbol[example.go#Server.Start]"))
+}
+
+func TestExtractGoSymbolMethodBodyOnly(t *testing.T) {
+	assert.Equal(t, []string{"\tfmt.Println(\"starting\")", "\treturn nil"}, extractGoSymbolTest(t, "insertGoSymbol[example.go#Server.Start body-only]"))
+}
+
+func TestExtractGoSymbolMethodSignatureOnly(t *testing.T) {
+	assert.Equal(t, []string{"func (s *Server) Start() error"}, extractGoSymbolTest(t, "insertGoSymbol[example.go#Server.Start signature-only]"))
+}
+
+func TestExtractGoSymbolSingleLineBodyOnly(t *testing.T) {
+	assert.Equal(t, []string{"return fmt.Sprintf(\"%s:%d\", s.Host, s.Port)"}, extractGoSymbolTest(t, "insertGoSymbol[example.go#Server.Address body-only]"))
+}
+
+func TestExtractGoSymbolType(t *testing.T) {
+	assert.Equal(t, []string{"// Server serves things", "type Server struct {", "\tPort int", "\tHost string", "}"}, extractGoSymbolTest(t, "insertGoSymbol[example.go#Server]"))
+}
+
+func TestExtractGoSymbolTypeBodyOnly(t *testing.T) {
+	assert.Equal(t, []string{"\tPort int", "\tHost string"}, extractGoSymbolTest(t, "insertGoSymbol[example.go#Server body-only]"))
+}
+
+func TestExtractGoSymbolTypeInBlock(t *testing.T) {
+	assert.Equal(t, []string{"// Handler handles things", "type Handler interface {", "\tHandle() error", "}"}, extractGoSymbolTest(t, "insertGoSymbol[example.go#Handler]"))
+	assert.Equal(t, []string{"type Id string"}, extractGoSymbolTest(t, "insertGoSymbol[example.go#Id]"))
+}
+
+func TestExtractGoSymbolConstBlock(t *testing.T) {
+	assert.Equal(t, []string{"const (", "\t// DefaultPort is the default port", "\tDefaultPort = 8080", "\tDefaultHost = \"localhost\"", ")"}, extractGoSymbolTest(t, "insertGoSymbol[example.go#DefaultHost]"))
+}
+
+func TestExtractGoSymbolErrors(t *testing.T) {
+	document, err := ParseDocument(Document{File: "src/example.go", Content: goSymbolSource})
+	assert.NoError(t, err)
+	documents := []ParsedDocument{document}
+
+	_, _, err = extractGoSymbol(documents, ParseMarker("insertGoSymbol[example.go#Yolo]"))
+	assert.EqualError(t, err, "symbol 'Yolo' not found")
+
+	_, _, err = extractGoSymbol(documents, ParseMarker("insertGoSymbol[example.go#Yolo.Start]"))
+	assert.EqualError(t, err, "symbol 'Yolo.Start' not found")
+
+	_, _, err = extractGoSymbol(documents, ParseMarker("insertGoSymbol[example.go]"))
+	assert.EqualError(t, err, "no symbol specified, expected format is 'file.go#Symbol'")
+
+	_, _, err = extractGoSymbol(documents, ParseMarker("insertGoSymbol[yolo.go#NewServer]"))
+	assert.EqualError(t, err, "file 'yolo.go' not found")
+
+	_, _, err = extractGoSymbol(documents, ParseMarker("insertGoSymbol[example.go#Server signature-only]"))
+	assert.EqualError(t, err, "signature-only is only supported for functions and methods")
+
+	_, _, err = extractGoSymbol(documents, ParseMarker("insertGoSymbol[example.go#Id body-only]"))
+	assert.EqualError(t, err, "body-only is only supported for functions, methods, structs and interfaces")
+}
+
+func TestReplaceGoSymbol(t *testing.T) {
+
+	target := `<!-- insertGoSymbol[example.go#Server.Start body-only] -->
+<!-- /insertGoSymbol -->`
+
+	targetReplaced := "<!-- insertGoSymbol[example.go#Server.Start body-only] -->\n```\nfmt.Println(\"starting\")\nreturn nil\n```\n\n<!-- /insertGoSymbol -->"
+
+	document1, err := ParseDocument(Document{File: "src/example.go", Content: goSymbolSource})
+	assert.NoError(t, err)
+
+	document2, err := ParseDocument(Document{File: "README.md", Content: target})
+	assert.NoError(t, err)
+
+	errors := ValidateDocuments([]ParsedDocument{document1, document2})
+	assert.Equal(t, 0, len(errors))
+
+	documents, err := ReplaceSnippets([]ParsedDocument{document1, document2}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, targetReplaced, documents[1].Content)
+}
@@ -0,0 +1,27 @@
+package pkg
+
+import "path/filepath"
+
+// ignoredDirs are never searched for documents, listed in trees or searched for input files
+var ignoredDirs = []string{".git", ".snex"}
+
+// IsIgnoredDir reports whether the directory at path is skipped by the file discovery, 'insertTree' and the input
+// files of cached commands. Besides '.git' and '.snex' this is the cache directory, which can be configured to be
+// outside of '.snex'.
+func (cache CacheConfig) IsIgnoredDir(path string) bool {
+	if contains(ignoredDirs, filepath.Base(path)) {
+		return true
+	}
+
+	if len(cache.Dir) == 0 {
+		return false
+	}
+
+	cacheDir, err := filepath.Abs(cache.Dir)
+	if err != nil {
+		return false
+	}
+
+	dir, err := filepath.Abs(path)
+	return err == nil && dir == cacheDir
+}
@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsIgnoredDir(t *testing.T) {
+	assert.True(t, CacheConfig{}.IsIgnoredDir(".git"))
+	assert.True(t, CacheConfig{}.IsIgnoredDir("docs/.snex"))
+	assert.False(t, CacheConfig{}.IsIgnoredDir("docs"))
+
+	cache := CacheConfig{Dir: "build/snex-cache"}
+	assert.True(t, cache.IsIgnoredDir("build/snex-cache"))
+	assert.True(t, cache.IsIgnoredDir("./build/snex-cache/"))
+	assert.False(t, cache.IsIgnoredDir("build"))
+	assert.False(t, cache.IsIgnoredDir("docs/snex-cache"))
+}
+
+func TestHashInputsIgnoresCacheDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "input.txt"), []byte("input"), 0o600))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "cache"), 0o700))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "cache", "output.txt"), []byte("cached"), 0o600))
+
+	hashes, err := hashInputs(dir, []string{"**/*.txt"}, CacheConfig{Dir: filepath.Join(dir, "cache")})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(hashes))
+	assert.NotZero(t, hashes["input.txt"])
+}
@@ -0,0 +1,324 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// insertContent is the content for an insert marker, before indentation and template are applied
+type insertContent struct {
+	Lines  []string
+	Source string
+	// Dedent is the default for the 'dedent' marker attribute
+	Dedent bool
+	// Raw content is inserted as-is without applying templates or escaping
+	Raw bool
+	// Output is inserted as a second block after Lines, e.g. the expected output of an example
+	Output []string
+}
+
+func getInsertContent(documents []ParsedDocument, document ParsedDocument, marker *SnippetMarker, config Config) (*insertContent, error) {
+	switch marker.Kind {
+	case KindInsertSnippet:
+		return &insertContent{Lines: getSnippetLines(documents, marker.Id), Source: getSnippetFile(documents, marker.Id), Dedent: true}, nil
+
+	case KindInsertFile:
+		file, fragment := splitFileId(marker.Id)
+		content := getContentForFile(documents, file)
+		if getDocumentForFile(documents, file) == nil {
+			var found bool
+			if content, found = readFileOnDisk(file, document.File); !found {
+				return nil, fmt.Errorf("file '%s' not found", file)
+			}
+		}
+
+		lines, err := extractLines(content, fragment, marker)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines, Source: file}, nil
+
+	case KindInsertGoSymbol:
+		lines, file, err := extractGoSymbol(documents, marker)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines, Source: file, Dedent: true}, nil
+
+	case KindInsertGoDoc:
+		lines, err := extractGoDoc(documents, marker, document.File)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines, Raw: true}, nil
+
+	case KindInsertGoApi:
+		lines, file, err := extractGoApi(documents, marker)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines, Source: file}, nil
+
+	case KindInsertBlock:
+		lines, file, err := extractBlock(documents, marker)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines, Source: file, Dedent: true}, nil
+
+	case KindInsertJson:
+		lines, file, err := extractJson(documents, marker)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines, Source: file}, nil
+
+	case KindInsertSection:
+		file, _ := splitFileId(marker.Id)
+		lines, err := extractSection(documents, marker)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines, Source: file, Raw: true}, nil
+
+	case KindInsertCommand:
+		lines, err := runCommand(config.Commands, config.Cache, marker, document.File)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines}, nil
+
+	case KindInsertOutput:
+		lines, err := runOutput(documents, config.Output, config.Cache, marker)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines}, nil
+
+	case KindInsertGoExample:
+		lines, output, file, err := extractGoExample(documents, marker)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines, Source: file, Output: output}, nil
+
+	case KindInsertTree:
+		lines, err := extractTree(config.Tree, config.Cache, marker, document.File)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines}, nil
+
+	case KindInsertTable:
+		lines, err := extractTable(documents, marker, document.File)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines, Raw: true}, nil
+
+	case KindInsertGoStruct:
+		lines, err := extractGoStruct(documents, marker)
+		if err != nil {
+			return nil, err
+		}
+
+		return &insertContent{Lines: lines, Raw: true}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported marker")
+}
+
+// readFileOnDisk returns the lines of file relative to the directory of the target file or the working directory,
+// for files that exist but were not read as documents, e.g. because they are not detected as text files
+func readFileOnDisk(file string, target string) ([]string, bool) {
+	for _, candidate := range []string{filepath.Join(filepath.Dir(target), file), file} {
+		content, err := os.ReadFile(candidate)
+		if err != nil {
+			continue
+		}
+
+		document, err := ParseDocument(Document{File: file, Content: string(content)})
+		if err != nil {
+			continue
+		}
+
+		return getContentForFile([]ParsedDocument{document}, file), true
+	}
+
+	return nil, false
+}
+
+func renderInsert(documents []ParsedDocument, document ParsedDocument, line DocumentLine, template string, config Config) ([]string, error) {
+	marker := line.Snippet
+
+	content, err := getInsertContent(documents, document, marker, config)
+	if err != nil {
+		return nil, insertError(document, line, err)
+	}
+
+	lines, err := replaceVars(content.Lines, config.Vars)
+	if err != nil {
+		return nil, insertError(document, line, err)
+	}
+
+	lines, err = formatIndentation(lines, marker, content.Dedent)
+	if err != nil {
+		return nil, insertError(document, line, err)
+	}
+
+	// content wrapped by a template is shown as code, so its links are only rewritten if requested explicitly
+	links := marker.Attribute("links", "keep")
+	if content.Raw {
+		links = marker.Attribute("links", "rewrite")
+	}
+
+	if len(content.Source) > 0 && links == "rewrite" {
+		if source := getDocumentForFile(documents, content.Source); source != nil {
+			lines = rewriteLinks(lines, source.File, document.File)
+		}
+	}
+
+	if content.Raw {
+		return lines, nil
+	}
+
+	lines, err = executeTemplateWithDefault(lines, document.File, content.Source, template, marker.Attribute("escape", ""), config.Vars, config.SnippetTemplates())
+	if err != nil || len(content.Output) == 0 {
+		return lines, err
+	}
+
+	output, err := replaceVars(content.Output, config.Vars)
+	if err != nil {
+		return nil, insertError(document, line, err)
+	}
+
+	output, err = executeTemplateWithDefault(output, document.File, "", template, marker.Attribute("escape", ""), config.Vars, config.SnippetTemplates())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) > 0 && !isBlank(lines[len(lines)-1]) {
+		lines = append(lines, "")
+	}
+
+	return append(lines, output...), nil
+}
+
+func insertError(document ParsedDocument, line DocumentLine, err error) error {
+	return fmt.Errorf("could not insert '%s' into '%s:%d': %s", line.Snippet.Id, document.File, line.number+1, err)
+}
+
+func validateInserts(documents []ParsedDocument) []error {
+	var errors []error
+
+	for _, document := range documents {
+		for _, line := range document.Lines {
+			snippet := line.Snippet
+			// commands and programs are only run when replacing
+			if snippet == nil || !snippet.IsInsert() || !snippet.IsStart || snippet.Kind == KindInsertSnippet || snippet.Kind == KindInsertCommand || snippet.Kind == KindInsertOutput {
+				continue
+			}
+
+			if _, err := getInsertContent(documents, document, snippet, Config{}); err != nil {
+				errors = append(errors, insertError(document, line, err))
+			}
+		}
+	}
+
+	return errors
+}
+
+// ReplaceSnippets replaces the content of all insert markers with the default config
+func ReplaceSnippets(documents []ParsedDocument, template string) ([]Document, error) {
+	return ReplaceSnippetsWithConfig(documents, template, Config{})
+}
+
+// ReplaceSnippetsWithConfig replaces the content of all insert markers like ReplaceSnippets, using the settings from
+// config for inserts that need them
+func ReplaceSnippetsWithConfig(documents []ParsedDocument, template string, config Config) ([]Document, error) {
+	order, err := resolveInsertOrder(documents)
+	if err != nil {
+		return nil, err
+	}
+
+	// regions are resolved on a copy of the documents, so inserts can read the already resolved content of other
+	// regions they depend on
+	resolved := make([]ParsedDocument, len(documents))
+	for index, document := range documents {
+		resolved[index] = ParsedDocument{File: document.File, Lines: append([]DocumentLine{}, document.Lines...)}
+	}
+
+	// inline values are replaced before the inserts, so inserted content shows the current values, markers inside of
+	// insert regions are skipped
+	values := map[string]string{}
+	for _, document := range resolved {
+		if err := replaceInlineValues(resolved, document, config.Values, values); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, region := range order {
+		document := &resolved[region.Document]
+		line := document.Lines[region.Start]
+
+		enabled, err := isInsertEnabled(resolved, line.Snippet, config.Profiles.Active)
+		if err != nil {
+			return nil, insertError(*document, line, err)
+		}
+
+		// the placeholder for inserts whose conditions are not met is plain text, markers inside of it are not parsed
+		renderedLines := config.Profiles.placeholderLines()
+		if enabled {
+			renderedLines, err = renderInsert(resolved, *document, line, template, config)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		var lines []DocumentLine
+		for _, renderedLine := range renderedLines {
+			var snippet *SnippetMarker
+			if enabled {
+				snippet = ParseMarker(renderedLine)
+			}
+			lines = append(lines, DocumentLine{line: renderedLine, number: line.number, Snippet: snippet})
+		}
+
+		document.Lines = append(document.Lines[:region.Start+1], append(lines, document.Lines[region.End:]...)...)
+
+		delta := len(lines) - (region.End - region.Start - 1)
+		for _, other := range order {
+			if other.Document == region.Document && other.Start > region.Start {
+				other.Start += delta
+				other.End += delta
+			}
+		}
+		region.End += delta
+	}
+
+	var replacedDocuments []Document
+	for _, document := range resolved {
+		var lines []string
+		for _, line := range document.Lines {
+			lines = append(lines, line.line)
+		}
+
+		replacedDocuments = append(replacedDocuments, Document{document.File, strings.Join(lines, "\n")})
+	}
+
+	return replacedDocuments, nil
+}
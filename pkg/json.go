@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseJsonPointer splits a RFC 6901 JSON pointer like '/paths/~1users/get' into its unescaped reference tokens
+func parseJsonPointer(pointer string) ([]string, error) {
+	if len(pointer) == 0 {
+		return []string{}, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer '%s', pointer must start with '/'", pointer)
+	}
+
+	var tokens []string
+	for _, token := range strings.Split(pointer[1:], "/") {
+		tokens = append(tokens, strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~"))
+	}
+
+	return tokens, nil
+}
+
+// formatJsonPointer is the inverse of parseJsonPointer
+func formatJsonPointer(tokens []string) string {
+	pointer := ""
+	for _, token := range tokens {
+		pointer += "/" + strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+	}
+
+	return pointer
+}
+
+// jsonChild returns the raw value of the object member or array element token inside of the raw JSON value,
+// decoding only the surrounding structure so the original member order is preserved
+func jsonChild(raw json.RawMessage, token string) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+
+	delimiter, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch delimiter {
+	case json.Delim('{'):
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return nil, err
+			}
+
+			if key == token {
+				return value, nil
+			}
+		}
+
+		return nil, fmt.Errorf("member '%s' not found", token)
+
+	case json.Delim('['):
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+			return nil, fmt.Errorf("invalid array index '%s'", token)
+		}
+
+		for i := 0; decoder.More(); i++ {
+			var value json.RawMessage
+			if err := decoder.Decode(&value); err != nil {
+				return nil, err
+			}
+
+			if i == index {
+				return value, nil
+			}
+		}
+
+		return nil, fmt.Errorf("array index %d out of bounds", index)
+	}
+
+	return nil, fmt.Errorf("can not resolve '%s' inside of a scalar value", token)
+}
+
+// extractJson returns the JSON value referenced like 'file.json#/paths/~1users/get', re-serialized with the
+// number of spaces set by the 'json-indent' attribute (default 2), where 'json-indent=0' produces compact JSON
+func extractJson(documents []ParsedDocument, marker *SnippetMarker) ([]string, string, error) {
+	file, pointer := splitFileId(marker.Id)
+
+	if getDocumentForFile(documents, file) == nil {
+		return nil, "", fmt.Errorf("file '%s' not found", file)
+	}
+
+	tokens, err := parseJsonPointer(pointer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	indent, err := marker.IntAttribute("json-indent", 2)
+	if err != nil {
+		return nil, "", err
+	}
+
+	raw := json.RawMessage(strings.Join(getContentForFile(documents, file), "\n"))
+	if !json.Valid(raw) {
+		return nil, "", fmt.Errorf("file '%s' is not valid JSON", file)
+	}
+
+	for index, token := range tokens {
+		raw, err = jsonChild(raw, token)
+		if err != nil {
+			return nil, "", fmt.Errorf("could not resolve '%s': %s", formatJsonPointer(tokens[:index+1]), err)
+		}
+	}
+
+	formatted := new(bytes.Buffer)
+	if indent == 0 {
+		err = json.Compact(formatted, raw)
+	} else {
+		err = json.Indent(formatted, bytes.TrimSpace(raw), "", strings.Repeat(" ", indent))
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return strings.Split(formatted.String(), "\n"), file, nil
+}
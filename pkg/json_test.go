@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+var openApiSource = `{
+  "openapi": "3.0.0",
+  "paths": {
+    "/users": {
+      "get": {"summary": "List users", "tags": ["users", "admin"], "operationId": "listUsers"}
+    },
+    "/a~b": {"get": {"summary": "Tilde"}}
+  }
+}`
+
+func extractJsonTest(t *testing.T, marker string) ([]string, error) {
+	document, err := ParseDocument(Document{File: "api/openapi.json", Content: openApiSource})
+	assert.NoError(t, err)
+
+	lines, _, err := extractJson([]ParsedDocument{document}, ParseMarker(marker))
+	return lines, err
+}
+
+func TestParseJsonPointer(t *testing.T) {
+	tokens, err := parseJsonPointer("/paths/~1users/a~0b/0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"paths", "/users", "a~b", "0"}, tokens)
+
+	tokens, err = parseJsonPointer("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, tokens)
+
+	_, err = parseJsonPointer("paths")
+	assert.Error(t, err)
+}
+
+func TestExtractJsonPointer(t *testing.T) {
+	lines, err := extractJsonTest(t, "insertJson[openapi.json#/paths/~1users/get]")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"{",
+		`  "summary": "List users",`,
+		`  "tags": [`,
+		`    "users",`,
+		`    "admin"`,
+		"  ],",
+		`  "operationId": "listUsers"`,
+		"}",
+	}, lines)
+}
+
+func TestExtractJsonArrayElementAndTilde(t *testing.T) {
+	lines, err := extractJsonTest(t, "insertJson[openapi.json#/paths/~1users/get/tags/1]")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`"admin"`}, lines)
+
+	lines, err = extractJsonTest(t, "insertJson[openapi.json#/paths/~1a~0b json-indent=0]")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{"get":{"summary":"Tilde"}}`}, lines)
+}
+
+func TestExtractJsonErrors(t *testing.T) {
+	_, err := extractJsonTest(t, "insertJson[openapi.json#/paths/~1orders]")
+	assert.EqualError(t, err, "could not resolve '/paths/~1orders': member '/orders' not found")
+
+	_, err = extractJsonTest(t, "insertJson[openapi.json#/paths/~1users/get/tags/2]")
+	assert.EqualError(t, err, "could not resolve '/paths/~1users/get/tags/2': array index 2 out of bounds")
+
+	_, err = extractJsonTest(t, "insertJson[openapi.json#/openapi/version]")
+	assert.Error(t, err)
+
+	_, err = extractJsonTest(t, "insertJson[missing.json#/paths]")
+	assert.EqualError(t, err, "file 'missing.json' not found")
+}
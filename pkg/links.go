@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var linkFilePatterns = []string{"*.md", "*.markdown", "*.mdx", "*.html", "*.htm"}
+
+var linkModes = []string{"rewrite", "keep"}
+
+// markdown inline links and images like '[text](url "title")' or '![alt](<url>)'
+var markdownLinkExpression = regexp.MustCompile(`(\]\(\s*<?)([^)\s>]+)`)
+
+// markdown link reference definitions like '[id]: url "title"'
+var markdownReferenceExpression = regexp.MustCompile(`^( {0,3}\[[^\]]+\]:\s*<?)([^\s>]+)`)
+
+// html attributes referencing other resources like 'src="img/arch.png"'
+var htmlLinkExpression = regexp.MustCompile(`(?i)(\b(?:src|href|poster)\s*=\s*["'])([^"']*)`)
+
+var urlSchemeExpression = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.\-]*:`)
+
+// isRelativeUrl reports whether url is a path relative to the document it appears in
+func isRelativeUrl(url string) bool {
+	return len(url) > 0 && !strings.HasPrefix(url, "#") && !strings.HasPrefix(url, "/") && !strings.HasPrefix(url, "{{") && !urlSchemeExpression.MatchString(url)
+}
+
+// rebaseUrl rewrites the relative url from a document in sourceDir so that it resolves to the same resource from a
+// document in targetDir, keeping query and fragment
+func rebaseUrl(url string, sourceDir string, targetDir string) string {
+	if !isRelativeUrl(url) {
+		return url
+	}
+
+	path, suffix := url, ""
+	if index := strings.IndexAny(url, "?#"); index != -1 {
+		path, suffix = url[:index], url[index:]
+	}
+
+	if len(path) == 0 {
+		return url
+	}
+
+	rebased, err := filepath.Rel(targetDir, filepath.Join(sourceDir, filepath.FromSlash(path)))
+	if err != nil {
+		return url
+	}
+
+	rebased = filepath.ToSlash(rebased)
+	if strings.HasSuffix(path, "/") && !strings.HasSuffix(rebased, "/") {
+		rebased += "/"
+	}
+
+	return rebased + suffix
+}
+
+// rewriteLinks rewrites all relative links and image paths in Markdown or HTML lines taken from the source file, so
+// they still resolve when the lines are inserted into the Markdown or HTML target file. Lines inside of fenced code
+// blocks and inline code spans are left untouched.
+func rewriteLinks(lines []string, source string, target string) []string {
+	sourceDir := filepath.Dir(source)
+	targetDir := filepath.Dir(target)
+
+	if !matchAnyPattern(linkFilePatterns, source) || !matchAnyPattern(linkFilePatterns, target) || filepath.Clean(sourceDir) == filepath.Clean(targetDir) {
+		return lines
+	}
+
+	rebase := func(expression *regexp.Regexp, text string) string {
+		return expression.ReplaceAllStringFunc(text, func(match string) string {
+			groups := expression.FindStringSubmatch(match)
+			return groups[1] + rebaseUrl(groups[2], sourceDir, targetDir)
+		})
+	}
+
+	var result []string
+
+	fence := ""
+	for _, line := range lines {
+		if len(fence) > 0 {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+			}
+			result = append(result, line)
+			continue
+		}
+
+		if match := markdownFenceExpression.FindStringSubmatch(line); match != nil {
+			fence = match[1]
+			result = append(result, line)
+			continue
+		}
+
+		// odd segments are inside of inline code spans
+		segments := strings.Split(line, "`")
+		for index := 0; index < len(segments); index += 2 {
+			segment := rebase(markdownLinkExpression, segments[index])
+			segment = rebase(htmlLinkExpression, segment)
+			if index == 0 {
+				segment = rebase(markdownReferenceExpression, segment)
+			}
+			segments[index] = segment
+		}
+
+		result = append(result, strings.Join(segments, "`"))
+	}
+
+	return result
+}
@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+func TestRebaseUrl(t *testing.T) {
+	assert.Equal(t, "docs/img/arch.png", rebaseUrl("img/arch.png", "docs", "."))
+	assert.Equal(t, "../shared/img/arch.png", rebaseUrl("img/arch.png", "shared", "docs"))
+	assert.Equal(t, "README.md#usage", rebaseUrl("../README.md#usage", "docs/install", "docs"))
+	assert.Equal(t, "docs/guide/", rebaseUrl("guide/", "docs", "."))
+	assert.Equal(t, "https://example.org/img.png", rebaseUrl("https://example.org/img.png", "docs", "."))
+	assert.Equal(t, "mailto:info@example.org", rebaseUrl("mailto:info@example.org", "docs", "."))
+	assert.Equal(t, "/img/logo.png", rebaseUrl("/img/logo.png", "docs", "."))
+	assert.Equal(t, "#installation", rebaseUrl("#installation", "docs", "."))
+}
+
+func TestRewriteLinks(t *testing.T) {
+	assert.Equal(t, []string{
+		"![diagram](docs/img/arch.png) see [install](docs/install.md#linux \"Install\")",
+		`<img src="docs/img/logo.png"> <a href='https://example.org'>`,
+		"[ref]: docs/guide.md",
+		"`[code](img/arch.png)` [link](<docs/a b.md>)",
+		"```markdown",
+		"![diagram](img/arch.png)",
+		"```",
+	}, rewriteLinks([]string{
+		"![diagram](img/arch.png) see [install](install.md#linux \"Install\")",
+		`<img src="img/logo.png"> <a href='https://example.org'>`,
+		"[ref]: guide.md",
+		"`[code](img/arch.png)` [link](<a b.md>)",
+		"```markdown",
+		"![diagram](img/arch.png)",
+		"```",
+	}, "docs/install/../shared.md", "README.md"))
+}
+
+func TestRewriteLinksSameDirectoryOrOtherFormat(t *testing.T) {
+	lines := []string{"![diagram](img/arch.png)"}
+
+	assert.Equal(t, lines, rewriteLinks(lines, "docs/install.md", "docs/README.md"))
+	assert.Equal(t, lines, rewriteLinks(lines, "docs/install.md", "main.go"))
+	assert.Equal(t, lines, rewriteLinks(lines, "docs/install.txt", "README.md"))
+}
+
+func TestReplaceFileKeepsLinks(t *testing.T) {
+	document1, err := ParseDocument(Document{File: "docs/install.md", Content: "![diagram](img/arch.png)"})
+	assert.NoError(t, err)
+
+	document2, err := ParseDocument(Document{File: "README.md", Content: "<!-- insertFile[docs/install.md] -->\n<!-- /insertFile -->\n<!-- insertFile[docs/install.md links=rewrite] -->\n<!-- /insertFile -->"})
+	assert.NoError(t, err)
+
+	replaced, err := ReplaceSnippets([]ParsedDocument{document1, document2}, "{{.Content}}")
+	assert.NoError(t, err)
+	assert.Equal(t, "<!-- insertFile[docs/install.md] -->\n![diagram](img/arch.png)\n<!-- /insertFile -->\n<!-- insertFile[docs/install.md links=rewrite] -->\n![diagram](docs/img/arch.png)\n<!-- /insertFile -->", replaced[1].Content)
+}
+
+func TestReplaceSectionRewritesLinks(t *testing.T) {
+	document1, err := ParseDocument(Document{File: "docs/install.md", Content: "## Installation\n![diagram](img/arch.png)\n"})
+	assert.NoError(t, err)
+
+	document2, err := ParseDocument(Document{File: "README.md", Content: "<!-- insertSection[docs/install.md#Installation] -->\n<!-- /insertSection -->\n<!-- insertSection[docs/install.md#Installation links=keep] -->\n<!-- /insertSection -->"})
+	assert.NoError(t, err)
+
+	replaced, err := ReplaceSnippets([]ParsedDocument{document1, document2}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "<!-- insertSection[docs/install.md#Installation] -->\n## Installation\n![diagram](docs/img/arch.png)\n<!-- /insertSection -->\n<!-- insertSection[docs/install.md#Installation links=keep] -->\n## Installation\n![diagram](img/arch.png)\n<!-- /insertSection -->", replaced[1].Content)
+}
@@ -2,41 +2,100 @@ package pkg
 
 import "regexp"
 
-var snippetStartExpression = regexp.MustCompile(`[^|\s]*snippet\[\s*([a-zA-Z0-9_\-]*)\s*\][\s|$]*`)
-var snippetEndExpression = regexp.MustCompile(`[^|\s]*/snippet[\s|$]*`)
+const snippetIdPattern = `[a-zA-Z0-9_\-]*`
+const fileIdPattern = `[a-zA-Z0-9_\-\\./#~{}%@+]*`
 
-var insertSnippetStartExpression = regexp.MustCompile(`[^|\s]*insertSnippet\[\s*([a-zA-Z0-9_\-]*)\s*\][\s|$]*`)
-var insertSnippetEndExpression = regexp.MustCompile(`[^|\s]*/insertSnippet[\s|$]*`)
+const attributesPattern = `((?:\s+[a-zA-Z][a-zA-Z0-9_\-]*(?:=(?:"[^"]*"|[^\s\]"]*))?)*)`
 
-var insertFileStartExpression = regexp.MustCompile(`[^|\s]*insertFile\[\s*([a-zA-Z0-9_\-\\.]*)\s*\][\s|$]*`)
-var insertFileEndExpression = regexp.MustCompile(`[^|\s]*/insertFile[\s|$]*`)
+var attributeExpression = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9_\-]*)(?:=(?:"([^"]*)"|([^\s\]"]*)))?`)
 
-func ParseMarker(line string) *SnippetMarker {
-	snippetStart := snippetStartExpression.FindStringSubmatch(line)
-	if len(snippetStart) == 2 {
-		return &SnippetMarker{IsSnippet: true, IsStart: true, Id: snippetStart[1]}
-	}
+// the command of insertCommand markers can contain whitespace, so command and attributes are split by parseCommandMarker
+var insertCommandStartExpression = regexp.MustCompile(`[^|\s]*insertCommand\[([^\]]*)\][\s|$]*`)
 
-	if snippetEndExpression.MatchString(line) {
-		return &SnippetMarker{IsSnippet: true, IsEnd: true}
-	}
+// markerDefinition describes how the start and end markers of one marker kind are parsed
+type markerDefinition struct {
+	kind  MarkerKind
+	start *regexp.Regexp
+	end   *regexp.Regexp
+	// parse splits the submatches of start into id and attributes
+	parse func(match []string) (string, map[string]string)
+}
 
-	if insertSnippetEndExpression.MatchString(line) {
-		return &SnippetMarker{IsInsertSnippet: true, IsEnd: true}
-	}
+// markerDefinitions are tried in order by ParseMarker
+var markerDefinitions = []markerDefinition{
+	newMarkerDefinition(KindSnippet, snippetIdPattern),
+	newMarkerDefinition(KindInsertSnippet, snippetIdPattern),
+	newMarkerDefinition(KindInsertFile, fileIdPattern),
+	newMarkerDefinition(KindInsertGoSymbol, fileIdPattern),
+	newMarkerDefinition(KindInsertGoDoc, fileIdPattern),
+	newMarkerDefinition(KindInsertGoApi, fileIdPattern),
+	newMarkerDefinition(KindInsertGoStruct, fileIdPattern),
+	newMarkerDefinition(KindInsertBlock, fileIdPattern),
+	newMarkerDefinition(KindInsertJson, fileIdPattern),
+	newMarkerDefinition(KindInsertSection, fileIdPattern),
+	newMarkerDefinition(KindInsertOutput, fileIdPattern),
+	newMarkerDefinition(KindInsertGoExample, fileIdPattern),
+	newMarkerDefinition(KindInsertTree, fileIdPattern),
+	newMarkerDefinition(KindInsertTable, fileIdPattern),
+	{
+		kind:  KindInsertCommand,
+		start: insertCommandStartExpression,
+		end:   endMarkerExpression(KindInsertCommand),
+		parse: func(match []string) (string, map[string]string) {
+			return parseCommandMarker(match[1])
+		},
+	},
+}
+
+// inlineValueExpression matches inline value markers like '<!--v:version-->1.2.3<!--/v-->', several of them can be
+// used on the same line
+var inlineValueExpression = regexp.MustCompile(`(<!--\s*v:([a-zA-Z0-9_\-.]+)\s*-->).*?(<!--\s*/v\s*-->)`)
 
-	insertSnippetStart := insertSnippetStartExpression.FindStringSubmatch(line)
-	if len(insertSnippetStart) == 2 {
-		return &SnippetMarker{IsInsertSnippet: true, IsStart: true, Id: insertSnippetStart[1]}
+func newMarkerDefinition(kind MarkerKind, idPattern string) markerDefinition {
+	return markerDefinition{
+		kind:  kind,
+		start: startMarkerExpression(string(kind), idPattern),
+		end:   endMarkerExpression(kind),
+		parse: func(match []string) (string, map[string]string) {
+			return match[1], parseAttributes(match[2])
+		},
 	}
+}
 
-	fileStart := insertFileStartExpression.FindStringSubmatch(line)
-	if len(fileStart) == 2 {
-		return &SnippetMarker{IsInsertFile: true, IsStart: true, Id: fileStart[1]}
+// endMarkerExpression matches end markers like '/name'
+func endMarkerExpression(kind MarkerKind) *regexp.Regexp {
+	return regexp.MustCompile(`[^|\s]*/` + string(kind) + `[\s|$]*`)
+}
+
+// startMarkerExpression matches start markers like 'name[id key1=value1 key2="value 2" key3]'
+func startMarkerExpression(name string, idPattern string) *regexp.Regexp {
+	return regexp.MustCompile(`[^|\s]*` + name + `\[\s*(` + idPattern + `)` + attributesPattern + `\s*\][\s|$]*`)
+}
+
+func parseAttributes(attributes string) map[string]string {
+	result := map[string]string{}
+
+	for _, match := range attributeExpression.FindAllStringSubmatch(attributes, -1) {
+		if len(match[2]) > 0 {
+			result[match[1]] = match[2]
+		} else {
+			result[match[1]] = match[3]
+		}
 	}
 
-	if insertFileEndExpression.MatchString(line) {
-		return &SnippetMarker{IsInsertFile: true, IsEnd: true}
+	return result
+}
+
+func ParseMarker(line string) *SnippetMarker {
+	for _, definition := range markerDefinitions {
+		if match := definition.start.FindStringSubmatch(line); match != nil {
+			id, attributes := definition.parse(match)
+			return &SnippetMarker{Kind: definition.kind, IsStart: true, Id: id, Attributes: attributes}
+		}
+
+		if definition.end.MatchString(line) {
+			return &SnippetMarker{Kind: definition.kind, IsEnd: true}
+		}
 	}
 
 	return nil
@@ -17,8 +17,8 @@ func TestParseMarkerSnippetStart(t *testing.T) {
 		marker := ParseMarker(line)
 		assert.NotZero(t, marker, line)
 		assert.True(t, marker.IsStart)
-		assert.True(t, marker.IsSnippet)
-		assert.False(t, marker.IsInsertFile)
+		assert.Equal(t, KindSnippet, marker.Kind)
+		assert.NotEqual(t, KindInsertFile, marker.Kind)
 		assert.False(t, marker.IsEnd)
 		assert.Equal(t, "id1", marker.Id)
 	}
@@ -32,9 +32,9 @@ func TestParseMarkerSnippetEnd(t *testing.T) {
 		marker := ParseMarker(line)
 		assert.NotZero(t, marker, line)
 		assert.False(t, marker.IsStart)
-		assert.True(t, marker.IsSnippet)
-		assert.False(t, marker.IsInsertSnippet, line)
-		assert.False(t, marker.IsInsertFile)
+		assert.Equal(t, KindSnippet, marker.Kind)
+		assert.NotEqual(t, KindInsertSnippet, marker.Kind, line)
+		assert.NotEqual(t, KindInsertFile, marker.Kind)
 		assert.True(t, marker.IsEnd)
 		assert.Zero(t, marker.Id)
 	}
@@ -48,9 +48,9 @@ func TestParseMarkerInsertSnippetStart(t *testing.T) {
 		marker := ParseMarker(line)
 		assert.NotZero(t, marker, line)
 		assert.True(t, marker.IsStart, line)
-		assert.False(t, marker.IsSnippet, line)
-		assert.False(t, marker.IsInsertFile, line)
-		assert.True(t, marker.IsInsertSnippet, line)
+		assert.NotEqual(t, KindSnippet, marker.Kind, line)
+		assert.NotEqual(t, KindInsertFile, marker.Kind, line)
+		assert.Equal(t, KindInsertSnippet, marker.Kind, line)
 		assert.False(t, marker.IsEnd, line)
 		assert.Equal(t, "id1", marker.Id)
 	}
@@ -64,9 +64,9 @@ func TestParseMarkerInsertSnippetEnd(t *testing.T) {
 		marker := ParseMarker(line)
 		assert.NotZero(t, marker, line)
 		assert.False(t, marker.IsStart, line)
-		assert.False(t, marker.IsSnippet, line)
-		assert.True(t, marker.IsInsertSnippet, line)
-		assert.False(t, marker.IsInsertFile, line)
+		assert.NotEqual(t, KindSnippet, marker.Kind, line)
+		assert.Equal(t, KindInsertSnippet, marker.Kind, line)
+		assert.NotEqual(t, KindInsertFile, marker.Kind, line)
 		assert.True(t, marker.IsEnd, line)
 		assert.Zero(t, marker.Id, line)
 	}
@@ -80,9 +80,9 @@ func TestParseMarkerInsertFileStart(t *testing.T) {
 		marker := ParseMarker(line)
 		assert.NotZero(t, marker, line)
 		assert.True(t, marker.IsStart, line)
-		assert.False(t, marker.IsSnippet, line)
-		assert.True(t, marker.IsInsertFile, line)
-		assert.False(t, marker.IsInsertSnippet, line)
+		assert.NotEqual(t, KindSnippet, marker.Kind, line)
+		assert.Equal(t, KindInsertFile, marker.Kind, line)
+		assert.NotEqual(t, KindInsertSnippet, marker.Kind, line)
 		assert.False(t, marker.IsEnd, line)
 		assert.Equal(t, "file1.txt", marker.Id, line)
 	}
@@ -96,10 +96,111 @@ func TestParseMarkerInsertFileEnd(t *testing.T) {
 		marker := ParseMarker(line)
 		assert.NotZero(t, marker, line)
 		assert.False(t, marker.IsStart, line)
-		assert.False(t, marker.IsSnippet, line)
-		assert.False(t, marker.IsInsertSnippet, line)
-		assert.True(t, marker.IsInsertFile, line)
+		assert.NotEqual(t, KindSnippet, marker.Kind, line)
+		assert.NotEqual(t, KindInsertSnippet, marker.Kind, line)
+		assert.Equal(t, KindInsertFile, marker.Kind, line)
 		assert.True(t, marker.IsEnd, line)
 		assert.Zero(t, marker.Id, line)
 	}
 }
+
+func TestParseMarkerAttributes(t *testing.T) {
+	marker := ParseMarker(`<!-- insertSnippet[id1 escape=html title="some title" flag] -->`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertSnippet, marker.Kind)
+	assert.Equal(t, "id1", marker.Id)
+	assert.Equal(t, map[string]string{"escape": "html", "title": "some title", "flag": ""}, marker.Attributes)
+	assert.Equal(t, "html", marker.Attribute("escape", "none"))
+	assert.Equal(t, "none", marker.Attribute("unknown", "none"))
+}
+
+func TestParseMarkerInsertFileAttributes(t *testing.T) {
+	marker := ParseMarker(`insertFile[ file1.txt escape=json ]`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertFile, marker.Kind)
+	assert.Equal(t, "file1.txt", marker.Id)
+	assert.Equal(t, "json", marker.Attribute("escape", ""))
+}
+
+func TestParseMarkerNoMarker(t *testing.T) {
+	assert.Zero(t, ParseMarker("lines := snippet[1:]"))
+}
+
+func TestParseMarkerInsertFileRange(t *testing.T) {
+	marker := ParseMarker(`<!-- insertFile[src/main.go#L10-L42] -->`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertFile, marker.Kind)
+	assert.Equal(t, "src/main.go#L10-L42", marker.Id)
+}
+
+func TestParseMarkerInsertGoSymbol(t *testing.T) {
+	marker := ParseMarker(`<!-- insertGoSymbol[pkg/server.go#Server.Start body-only] -->`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertGoSymbol, marker.Kind)
+	assert.True(t, marker.IsStart)
+	assert.Equal(t, "pkg/server.go#Server.Start", marker.Id)
+	assert.Equal(t, map[string]string{"body-only": ""}, marker.Attributes)
+
+	marker = ParseMarker(`<!-- /insertGoSymbol -->`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertGoSymbol, marker.Kind)
+	assert.True(t, marker.IsEnd)
+}
+
+func TestParseMarkerInsertGoDoc(t *testing.T) {
+	marker := ParseMarker(`<!-- insertGoDoc[pkg/server#Server format=text] -->`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertGoDoc, marker.Kind)
+	assert.NotEqual(t, KindInsertGoApi, marker.Kind)
+	assert.Equal(t, "pkg/server#Server", marker.Id)
+	assert.Equal(t, "text", marker.Attribute("format", ""))
+
+	marker = ParseMarker(`<!-- insertGoApi[pkg/server] -->`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertGoApi, marker.Kind)
+	assert.NotEqual(t, KindInsertGoDoc, marker.Kind)
+	assert.Equal(t, "pkg/server", marker.Id)
+
+	assert.Equal(t, KindInsertGoDoc, ParseMarker(`<!-- /insertGoDoc -->`).Kind)
+	assert.Equal(t, KindInsertGoApi, ParseMarker(`<!-- /insertGoApi -->`).Kind)
+}
+
+func TestParseMarkerInsertGoStruct(t *testing.T) {
+	marker := ParseMarker(`<!-- insertGoStruct[pkg/config.go#Config columns="field,env:Variable"] -->`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertGoStruct, marker.Kind)
+	assert.Equal(t, "pkg/config.go#Config", marker.Id)
+	assert.Equal(t, "field,env:Variable", marker.Attribute("columns", ""))
+
+	assert.Equal(t, KindInsertGoStruct, ParseMarker(`<!-- /insertGoStruct -->`).Kind)
+}
+
+func TestParseMarkerInsertBlock(t *testing.T) {
+	marker := ParseMarker(`// insertBlock[src/Greeter.java#Greeter.greet]`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertBlock, marker.Kind)
+	assert.Equal(t, "src/Greeter.java#Greeter.greet", marker.Id)
+
+	assert.Equal(t, KindInsertBlock, ParseMarker(`// /insertBlock`).Kind)
+}
+
+func TestParseMarkerInsertJson(t *testing.T) {
+	marker := ParseMarker(`<!-- insertJson[api/openapi.json#/paths/~1users~1{id}/get json-indent=4] -->`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertJson, marker.Kind)
+	assert.Equal(t, "api/openapi.json#/paths/~1users~1{id}/get", marker.Id)
+	assert.Equal(t, "4", marker.Attribute("json-indent", ""))
+
+	assert.Equal(t, KindInsertJson, ParseMarker(`<!-- /insertJson -->`).Kind)
+}
+
+func TestParseMarkerInsertSection(t *testing.T) {
+	marker := ParseMarker(`<!-- insertSection[docs/install.md#Installation shift=1 heading=false] -->`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertSection, marker.Kind)
+	assert.Equal(t, "docs/install.md#Installation", marker.Id)
+	assert.Equal(t, "1", marker.Attribute("shift", ""))
+	assert.Equal(t, "false", marker.Attribute("heading", ""))
+
+	assert.Equal(t, KindInsertSection, ParseMarker(`<!-- /insertSection -->`).Kind)
+}
@@ -0,0 +1,206 @@
+package pkg
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OutputConfig configures how 'insertOutput' runs programs and normalizes their output, running programs is disabled
+// as long as Enabled is false
+type OutputConfig struct {
+	// Enabled allows 'insertOutput' to run programs
+	Enabled bool `json:"enabled"`
+	// Runners maps file extensions to the command running a file with that extension, where '{file}' is replaced
+	// with the file name, or '.' if a directory is referenced
+	Runners map[string]string `json:"runners"`
+	// Scrubbers are additional named scrubbers, a scrubber with the name of a builtin scrubber replaces it
+	Scrubbers map[string]Scrubber `json:"scrubbers"`
+	// Scrub lists the scrubbers that are used if the marker has no 'scrub' attribute
+	Scrub []string `json:"scrub"`
+	// Timeout is the default timeout for programs like '60s'
+	Timeout string `json:"timeout"`
+	// Env sets additional environment variables for programs
+	Env map[string]string `json:"env"`
+}
+
+// Scrubber replaces all matches of the regular expression Pattern in the output with Replacement
+type Scrubber struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+var defaultOutputRunners = map[string]string{
+	"go": "go run {file}",
+}
+
+var defaultScrubbers = map[string]Scrubber{
+	"timestamp": {Pattern: `\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?`, Replacement: "<timestamp>"},
+	"temp-path": {Pattern: `(?:/private)?(?:/tmp|/var/folders)/[^\s"':]*`, Replacement: "<temp>"},
+	"uuid":      {Pattern: `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`, Replacement: "<uuid>"},
+}
+
+func (config OutputConfig) runner(extension string) (string, bool) {
+	if runner, ok := config.Runners[extension]; ok {
+		return runner, true
+	}
+
+	runner, ok := defaultOutputRunners[extension]
+	return runner, ok
+}
+
+func (config OutputConfig) scrubber(name string) (Scrubber, bool) {
+	if scrubber, ok := config.Scrubbers[name]; ok {
+		return scrubber, true
+	}
+
+	scrubber, ok := defaultScrubbers[name]
+	return scrubber, ok
+}
+
+// scrubberNames returns the names of all builtin and configured scrubbers
+func (config OutputConfig) scrubberNames() []string {
+	var names []string
+	for name := range defaultScrubbers {
+		names = append(names, name)
+	}
+
+	for name := range config.Scrubbers {
+		if _, ok := defaultScrubbers[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks the runners and scrubbers of the config
+func (config OutputConfig) Validate() error {
+	for name, scrubber := range config.Scrubbers {
+		if _, err := regexp.Compile(scrubber.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern for scrubber '%s': %s", name, err)
+		}
+	}
+
+	for _, name := range config.Scrub {
+		if _, ok := config.scrubber(name); !ok {
+			return fmt.Errorf("unknown scrubber '%s', available scrubbers are: %s", name, strings.Join(config.scrubberNames(), ", "))
+		}
+	}
+
+	for extension, runner := range config.Runners {
+		if _, _, err := splitCommandLine(runner); err != nil {
+			return fmt.Errorf("invalid runner for '%s': %s", extension, err)
+		}
+	}
+
+	if len(config.Timeout) > 0 {
+		if _, err := parseCommandTimeout(config.Timeout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scrubOutput applies the scrubbers to all lines
+func scrubOutput(lines []string, config OutputConfig, names []string) ([]string, error) {
+	for _, name := range names {
+		scrubber, ok := config.scrubber(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown scrubber '%s', available scrubbers are: %s", name, strings.Join(config.scrubberNames(), ", "))
+		}
+
+		expression, err := regexp.Compile(scrubber.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for scrubber '%s': %s", name, err)
+		}
+
+		for index, line := range lines {
+			lines[index] = expression.ReplaceAllString(line, scrubber.Replacement)
+		}
+	}
+
+	return lines, nil
+}
+
+// outputProgram resolves the file or directory referenced by an 'insertOutput' marker to the directory the runner
+// is started in, the value for '{file}' and the file extension that selects the runner
+func outputProgram(documents []ParsedDocument, id string, config OutputConfig) (string, string, string, error) {
+	if document := getDocumentForFile(documents, id); document != nil {
+		extension := strings.TrimPrefix(filepath.Ext(document.File), ".")
+		return filepath.Dir(document.File), filepath.Base(document.File), extension, nil
+	}
+
+	dir := strings.TrimPrefix(path.Clean(filepath.ToSlash(id)), "./")
+	for _, document := range documents {
+		fileDir := filepath.ToSlash(filepath.Dir(document.File))
+		if fileDir != dir && !strings.HasSuffix(fileDir, "/"+dir) {
+			continue
+		}
+
+		extension := strings.TrimPrefix(filepath.Ext(document.File), ".")
+		if _, ok := config.runner(extension); ok {
+			return filepath.Dir(document.File), ".", extension, nil
+		}
+	}
+
+	return "", "", "", fmt.Errorf("file or directory '%s' not found", id)
+}
+
+// runOutput runs the file or directory referenced by an 'insertOutput' marker with the runner for its file extension
+// and returns the scrubbed output. Programs can depend on any other code, so the output is only cached if input files
+// are declared with the 'inputs' attribute, until the program or the declared inputs change.
+func runOutput(documents []ParsedDocument, config OutputConfig, cache CacheConfig, marker *SnippetMarker) ([]string, error) {
+	if !config.Enabled {
+		return nil, fmt.Errorf("running programs is disabled, set 'output.enabled' in the config file to enable it")
+	}
+
+	dir, file, extension, err := outputProgram(documents, marker.Id, config)
+	if err != nil {
+		return nil, err
+	}
+
+	runner, ok := config.runner(extension)
+	if !ok {
+		return nil, fmt.Errorf("no runner configured for '*.%s' files", extension)
+	}
+
+	args, _, err := splitCommandLine(runner)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, arg := range args {
+		args[index] = strings.ReplaceAll(arg, "{file}", file)
+	}
+
+	var inputs []string
+	if declared := parseInputPatterns(marker.Attribute("inputs", "")); len(declared) > 0 {
+		inputs = append([]string{"**"}, declared...)
+		if file != "." {
+			inputs[0] = file
+		}
+	}
+
+	lines, err := executeCommand(args, dir, config.Timeout, config.Env, marker, cache, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	scrub := config.Scrub
+	if value, ok := marker.Attributes["scrub"]; ok {
+		scrub = nil
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); len(name) > 0 {
+				scrub = append(scrub, name)
+			}
+		}
+	}
+
+	return scrubOutput(lines, config, scrub)
+}
@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeOutputProgramTest(t *testing.T, file string, content string) ParsedDocument {
+	file = filepath.Join(t.TempDir(), file)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0o700))
+	assert.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+	document, err := ParseDocument(Document{File: file, Content: content})
+	assert.NoError(t, err)
+
+	return document
+}
+
+func TestScrubOutput(t *testing.T) {
+	config := OutputConfig{Scrubbers: map[string]Scrubber{"duration": {Pattern: `\d+ms`, Replacement: "<duration>"}}}
+
+	lines, err := scrubOutput([]string{
+		"2024-01-02T10:11:12.123Z started in /tmp/go-build1234/b001/exe",
+		"request 0f8fad5b-d9cb-469f-a165-70867728950e took 42ms",
+	}, config, []string{"timestamp", "temp-path", "uuid", "duration"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"<timestamp> started in <temp>",
+		"request <uuid> took <duration>",
+	}, lines)
+
+	_, err = scrubOutput([]string{}, config, []string{"unknown"})
+	assert.EqualError(t, err, "unknown scrubber 'unknown', available scrubbers are: duration, temp-path, timestamp, uuid")
+}
+
+func TestParseMarkerInsertOutput(t *testing.T) {
+	marker := ParseMarker(`<!-- insertOutput[examples/hello scrub=timestamp,uuid] -->`)
+	assert.NotZero(t, marker)
+	assert.Equal(t, KindInsertOutput, marker.Kind)
+	assert.Equal(t, "examples/hello", marker.Id)
+	assert.Equal(t, "timestamp,uuid", marker.Attribute("scrub", ""))
+
+	assert.Equal(t, KindInsertOutput, ParseMarker(`<!-- /insertOutput -->`).Kind)
+}
+
+func TestRunOutputConfiguredRunner(t *testing.T) {
+	document := writeOutputProgramTest(t, "examples/hello.txt", "hello 2024-01-02 10:11:12\n")
+	config := OutputConfig{Enabled: true, Runners: map[string]string{"txt": "cat {file}"}, Scrub: []string{"timestamp"}}
+
+	lines, err := runOutput([]ParsedDocument{document}, config, CacheConfig{}, ParseMarker("insertOutput[examples/hello.txt]"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello <timestamp>"}, lines)
+
+	lines, err = runOutput([]ParsedDocument{document}, config, CacheConfig{}, ParseMarker("insertOutput[examples/hello.txt scrub=]"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello 2024-01-02 10:11:12"}, lines)
+
+	_, err = runOutput([]ParsedDocument{document}, OutputConfig{Enabled: true}, CacheConfig{}, ParseMarker("insertOutput[examples/hello.txt]"))
+	assert.EqualError(t, err, "no runner configured for '*.txt' files")
+
+	_, err = runOutput([]ParsedDocument{document}, config, CacheConfig{}, ParseMarker("insertOutput[examples/other]"))
+	assert.EqualError(t, err, "file or directory 'examples/other' not found")
+}
+
+func TestRunOutputDisabled(t *testing.T) {
+	document := writeOutputProgramTest(t, "examples/hello.txt", "hello\n")
+	config := OutputConfig{Runners: map[string]string{"txt": "cat {file}"}}
+
+	_, err := runOutput([]ParsedDocument{document}, config, CacheConfig{}, ParseMarker("insertOutput[examples/hello.txt]"))
+	assert.EqualError(t, err, "running programs is disabled, set 'output.enabled' in the config file to enable it")
+}
+
+func TestRunOutputCachedWithInputs(t *testing.T) {
+	document := writeOutputProgramTest(t, "examples/hello.txt", "hello\n")
+	dir := filepath.Dir(document.File)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "lib.txt"), []byte("lib"), 0o600))
+
+	config := OutputConfig{Enabled: true, Runners: map[string]string{"txt": "cat {file}"}}
+	cache := CacheConfig{Dir: filepath.Join(t.TempDir(), "cache")}
+
+	_, err := runOutput([]ParsedDocument{document}, config, cache, ParseMarker("insertOutput[examples/hello.txt]"))
+	assert.NoError(t, err)
+	_, err = os.Stat(cache.Dir)
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = runOutput([]ParsedDocument{document}, config, cache, ParseMarker("insertOutput[examples/hello.txt inputs=lib.txt]"))
+	assert.NoError(t, err)
+	entries, err := os.ReadDir(cache.Dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+}
+
+func TestRunOutputGoDirectory(t *testing.T) {
+	document := writeOutputProgramTest(t, "examples/hello/main.go", "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n")
+	assert.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(document.File), "go.mod"), []byte("module hello\n\ngo 1.19\n"), 0o600))
+
+	lines, err := runOutput([]ParsedDocument{document}, OutputConfig{Enabled: true}, CacheConfig{}, ParseMarker("insertOutput[examples/hello timeout=2m]"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello"}, lines)
+}
@@ -3,6 +3,7 @@ package pkg
 import (
 	"bufio"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -22,17 +23,88 @@ type DocumentLine struct {
 	Snippet *SnippetMarker
 }
 
+// MarkerKind is the kind of a marker, named like the marker itself
+type MarkerKind string
+
+const (
+	KindSnippet         MarkerKind = "snippet"
+	KindInsertSnippet   MarkerKind = "insertSnippet"
+	KindInsertFile      MarkerKind = "insertFile"
+	KindInsertGoSymbol  MarkerKind = "insertGoSymbol"
+	KindInsertGoDoc     MarkerKind = "insertGoDoc"
+	KindInsertGoApi     MarkerKind = "insertGoApi"
+	KindInsertGoStruct  MarkerKind = "insertGoStruct"
+	KindInsertBlock     MarkerKind = "insertBlock"
+	KindInsertJson      MarkerKind = "insertJson"
+	KindInsertSection   MarkerKind = "insertSection"
+	KindInsertCommand   MarkerKind = "insertCommand"
+	KindInsertOutput    MarkerKind = "insertOutput"
+	KindInsertGoExample MarkerKind = "insertGoExample"
+	KindInsertTree      MarkerKind = "insertTree"
+	KindInsertTable     MarkerKind = "insertTable"
+)
+
 type SnippetMarker struct {
-	Id              string
-	IsSnippet       bool
-	IsInsertSnippet bool
-	IsInsertFile    bool
-	IsStart         bool
-	IsEnd           bool
+	Id         string
+	Attributes map[string]string
+	Kind       MarkerKind
+	IsStart    bool
+	IsEnd      bool
 }
 
 type SnippetMarkerPredicate func(marker *SnippetMarker) bool
 
+// Attribute returns the value of the marker attribute name, or defaultValue if the attribute is not set
+func (marker *SnippetMarker) Attribute(name string, defaultValue string) string {
+	value, ok := marker.Attributes[name]
+	if !ok {
+		return defaultValue
+	}
+
+	return value
+}
+
+// IsInsert reports whether the marker is one of the insert markers whose content gets replaced
+func (marker *SnippetMarker) IsInsert() bool {
+	return marker.Kind != KindSnippet
+}
+
+// BoolAttribute returns the boolean value of the marker attribute name, where an attribute without a value like
+// 'dedent' counts as true, or defaultValue if the attribute is not set
+func (marker *SnippetMarker) BoolAttribute(name string, defaultValue bool) (bool, error) {
+	value, ok := marker.Attributes[name]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	if len(value) == 0 {
+		return true, nil
+	}
+
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean value '%s' for attribute '%s'", value, name)
+	}
+
+	return result, nil
+}
+
+// IntAttribute returns the integer value of the marker attribute name, or defaultValue if the attribute is not set
+// or has no value
+func (marker *SnippetMarker) IntAttribute(name string, defaultValue int) (int, error) {
+	value := marker.Attribute(name, "")
+	if len(value) == 0 {
+		return defaultValue, nil
+	}
+
+	result, err := strconv.Atoi(value)
+	if err != nil || result < 0 {
+		return 0, fmt.Errorf("invalid number '%s' for attribute '%s'", value, name)
+	}
+
+	return result, nil
+}
+
 func ParseDocument(document Document) (ParsedDocument, error) {
 	var lines []DocumentLine
 	scanner := bufio.NewScanner(strings.NewReader(document.Content))
@@ -51,7 +123,14 @@ func ParseDocument(document Document) (ParsedDocument, error) {
 	return ParsedDocument{Lines: lines, File: document.File}, nil
 }
 
+// ValidateDocuments validates documents with the default config
 func ValidateDocuments(documents []ParsedDocument) []error {
+	return ValidateDocumentsWithConfig(documents, Config{})
+}
+
+// ValidateDocumentsWithConfig validates documents like ValidateDocuments, using the settings from config for markers
+// that need them
+func ValidateDocumentsWithConfig(documents []ParsedDocument, config Config) []error {
 	var errors []error
 
 	errors = append(errors, validateSnippetMarkerDuplicates(documents)...)
@@ -59,9 +138,12 @@ func ValidateDocuments(documents []ParsedDocument) []error {
 		return errors
 	}
 
-	errors = append(errors, validateNoInsertFileSelfReference(documents)...)
+	errors = append(errors, validateInsertCycles(documents)...)
 	errors = append(errors, validateMarkerStartEnd(documents)...)
 	errors = append(errors, validateSnippetsMissing(documents)...)
+	errors = append(errors, validateInserts(documents)...)
+	errors = append(errors, validateAttributes(documents)...)
+	errors = append(errors, validateInlineValues(documents, config.Values)...)
 
 	return errors
 }
@@ -73,11 +155,11 @@ func getSnippetLines(documents []ParsedDocument, id string) []string {
 		var lines []string
 		for _, line := range document.Lines {
 
-			if line.Snippet != nil && !foundSnippet && line.Snippet.IsSnippet && line.Snippet.Id == id && line.Snippet.IsStart {
+			if line.Snippet != nil && !foundSnippet && line.Snippet.Kind == KindSnippet && line.Snippet.Id == id && line.Snippet.IsStart {
 				foundSnippet = true
 			}
 
-			if line.Snippet != nil && foundSnippet && line.Snippet.IsSnippet && line.Snippet.IsEnd {
+			if line.Snippet != nil && foundSnippet && line.Snippet.Kind == KindSnippet && line.Snippet.IsEnd {
 				return lines
 			}
 
@@ -90,97 +172,72 @@ func getSnippetLines(documents []ParsedDocument, id string) []string {
 	return []string{}
 }
 
-func getContentForFile(documents []ParsedDocument, file string) []string {
+func getSnippetFile(documents []ParsedDocument, id string) string {
 	for _, document := range documents {
-		if strings.HasSuffix(document.File, file) {
-			var lines []string
-			for _, line := range document.Lines {
-				lines = append(lines, line.line)
+		for _, line := range document.Lines {
+			if line.Snippet != nil && line.Snippet.Kind == KindSnippet && line.Snippet.Id == id && line.Snippet.IsStart {
+				return document.File
 			}
-
-			return lines
 		}
 	}
 
-	return []string{}
+	return ""
 }
 
-func hasSnippet(documents []ParsedDocument, id string) bool {
+// getSnippetMarker returns the start marker of the snippet id or nil if the snippet does not exist
+func getSnippetMarker(documents []ParsedDocument, id string) *SnippetMarker {
 	for _, document := range documents {
 		for _, line := range document.Lines {
-			if line.Snippet != nil && line.Snippet.IsSnippet && line.Snippet.Id == id && line.Snippet.IsStart {
-				return true
+			if line.Snippet != nil && line.Snippet.Kind == KindSnippet && line.Snippet.Id == id && line.Snippet.IsStart {
+				return line.Snippet
 			}
 		}
 	}
 
-	return false
+	return nil
 }
 
-func ReplaceSnippets(documents []ParsedDocument, template string) ([]Document, error) {
-	var replacedDocuments []Document
-
-	for _, document := range documents {
-		var lines []string
-		isSnippet := false
-		for _, line := range document.Lines {
-			snippet := line.Snippet
-
-			if isSnippet {
-				if snippet != nil && (snippet.IsInsertSnippet || snippet.IsInsertFile) && snippet.IsEnd {
-					lines = append(lines, line.line)
-					isSnippet = false
-					continue
-				} else {
-					continue
-				}
-			}
-
-			if snippet != nil && !isSnippet && snippet.IsStart {
+func getDocumentForFile(documents []ParsedDocument, file string) *ParsedDocument {
+	for index, document := range documents {
+		if strings.HasSuffix(document.File, file) {
+			return &documents[index]
+		}
+	}
 
-				if snippet.IsInsertSnippet {
-					snippetLines := getSnippetLines(documents, snippet.Id)
-					snippetLines = removeIndentation(snippetLines)
+	return nil
+}
 
-					renderedLines, err := executeTemplateWithDefault(snippetLines, document.File, template)
-					if err != nil {
-						return nil, err
-					}
+func getContentForFile(documents []ParsedDocument, file string) []string {
+	document := getDocumentForFile(documents, file)
+	if document == nil {
+		return []string{}
+	}
 
-					isSnippet = true
-					lines = append(lines, line.line)
-					lines = append(lines, renderedLines...)
-					continue
-				}
+	var lines []string
+	for _, line := range document.Lines {
+		lines = append(lines, line.line)
+	}
 
-				if snippet.IsInsertFile {
-					snippetLines := getContentForFile(documents, snippet.Id)
-					renderedLines, err := executeTemplateWithDefault(snippetLines, document.File, template)
-					if err != nil {
-						return nil, err
-					}
+	return lines
+}
 
-					isSnippet = true
-					lines = append(lines, line.line)
-					lines = append(lines, renderedLines...)
-					continue
-				}
+func hasSnippet(documents []ParsedDocument, id string) bool {
+	for _, document := range documents {
+		for _, line := range document.Lines {
+			if line.Snippet != nil && line.Snippet.Kind == KindSnippet && line.Snippet.Id == id && line.Snippet.IsStart {
+				return true
 			}
-
-			lines = append(lines, line.line)
 		}
-
-		replacedDocuments = append(replacedDocuments, Document{document.File, strings.Join(lines, "\n")})
 	}
 
-	return replacedDocuments, nil
+	return false
 }
 
 func validateSnippetMarkerDuplicates(documents []ParsedDocument) []error {
 	var errors []error
 
 	errors = append(errors, validateDuplicates(documents, "start marker for snippet '%s' found more than once (%s)", func(marker *SnippetMarker) bool {
-		return marker.IsSnippet && marker.IsStart
+		return marker.Kind == KindSnippet && marker.IsStart
 	})...)
 
 	return errors
@@ -254,14 +311,17 @@ func collectSnippets(documents []ParsedDocument, predicate SnippetMarkerPredicat
 	return snippets
 }
 
-func validateNoInsertFileSelfReference(documents []ParsedDocument) []error {
+func validateSnippetsMissing(documents []ParsedDocument) []error {
 	var errors []error
 
 	for _, document := range documents {
 		for _, line := range document.Lines {
 			snippet := line.Snippet
-			if snippet != nil && snippet.IsInsertFile && snippet.IsStart && snippet.Id == document.File {
-				errors = append(errors, fmt.Errorf("insert file snippet '%s' references itself", document.File))
+			if snippet != nil && snippet.Kind == KindInsertSnippet && snippet.IsStart {
+
+				if !hasSnippet(documents, snippet.Id) {
+					errors = append(errors, fmt.Errorf("referenced snippet '%s' not found", snippet.Id))
+				}
 			}
 		}
 	}
@@ -269,16 +329,47 @@ func validateNoInsertFileSelfReference(documents []ParsedDocument) []error {
 	return errors
 }
 
-func validateSnippetsMissing(documents []ParsedDocument) []error {
+func validateAttributes(documents []ParsedDocument) []error {
 	var errors []error
 
 	for _, document := range documents {
 		for _, line := range document.Lines {
 			snippet := line.Snippet
-			if snippet != nil && snippet.IsInsertSnippet && snippet.IsStart {
+			if snippet == nil || !snippet.IsStart {
+				continue
+			}
 
-				if !hasSnippet(documents, snippet.Id) {
-					errors = append(errors, fmt.Errorf("referenced snippet '%s' not found", snippet.Id))
+			escape := snippet.Attribute("escape", "")
+			if len(escape) > 0 && !isValidEscape(escape) {
+				errors = append(errors, fmt.Errorf("unknown escape '%s' in '%s:%d', available escapes are: %s", escape, document.File, line.number+1, EscapeHelp))
+			}
+
+			links := snippet.Attribute("links", "")
+			if len(links) > 0 && !contains(linkModes, links) {
+				errors = append(errors, fmt.Errorf("unknown links mode '%s' in '%s:%d', available modes are: %s", links, document.File, line.number+1, strings.Join(linkModes, ", ")))
+			}
+
+			for _, name := range []string{"if", "tags"} {
+				if condition := snippet.Attribute(name, ""); len(condition) > 0 {
+					if _, err := parseCondition(condition); err != nil {
+						errors = append(errors, fmt.Errorf("%s in '%s:%d'", err, document.File, line.number+1))
+					}
+				}
+			}
+
+			if _, err := snippet.BoolAttribute("dedent", false); err != nil {
+				errors = append(errors, fmt.Errorf("%s in '%s:%d'", err, document.File, line.number+1))
+			}
+
+			for _, name := range []string{"indent", "json-indent"} {
+				if _, err := snippet.IntAttribute(name, 0); err != nil {
+					errors = append(errors, fmt.Errorf("%s in '%s:%d'", err, document.File, line.number+1))
+				}
+			}
+
+			if _, ok := snippet.Attributes["tabs-to-spaces"]; ok {
+				if _, err := tabWidth(snippet); err != nil {
+					errors = append(errors, fmt.Errorf("%s in '%s:%d'", err, document.File, line.number+1))
 				}
 			}
 		}
@@ -308,7 +399,7 @@ func validateDuplicates(documents []ParsedDocument, message string, predicate Sn
 func CountSnippets(document ParsedDocument) int {
 	count := 0
 	for _, line := range document.Lines {
-		if line.Snippet != nil && line.Snippet.IsSnippet && line.Snippet.IsStart {
+		if line.Snippet != nil && line.Snippet.Kind == KindSnippet && line.Snippet.IsStart {
 			count++
 		}
 	}
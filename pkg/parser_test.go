@@ -2,6 +2,8 @@ package pkg
 
 import (
 	"github.com/alecthomas/assert/v2"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -153,7 +155,7 @@ ipsum`
 
 	errors := ValidateDocuments([]ParsedDocument{document})
 	assert.Equal(t, 1, len(errors))
-	assert.Equal(t, "insert file snippet 'file1' references itself", errors[0].Error())
+	assert.Equal(t, "insert cycle detected: file1:2 -> file1:2", errors[0].Error())
 }
 
 func TestValidateDocumentsSnippetMissing(t *testing.T) {
@@ -427,3 +429,200 @@ ipsum
 	assert.Equal(t, "target", documents[1].File)
 	assert.Equal(t, targetReplaced, documents[1].Content)
 }
+
+func TestValidateDocumentsUnknownEscape(t *testing.T) {
+
+	content := `lorem
+insertSnippet[id1 escape=yolo]
+/insertSnippet
+snippet[id1]
+/snippet`
+
+	document, err := ParseDocument(Document{File: "file1", Content: content})
+	assert.NoError(t, err)
+
+	errors := ValidateDocuments([]ParsedDocument{document})
+	assert.Equal(t, 1, len(errors))
+	assert.Equal(t, "unknown escape 'yolo' in 'file1:2', available escapes are: none, html, xml, json, yaml, go", errors[0].Error())
+}
+
+func TestReplaceSnippetsEscapeHtml(t *testing.T) {
+
+	source := `snippet[id1]
+if a < b {
+}
+/snippet`
+
+	target := `<!-- insertSnippet[id1] -->
+<!-- /insertSnippet -->`
+
+	targetReplaced := `<!-- insertSnippet[id1] -->
+<pre><code class="language-go">if a &lt; b {
+}</code></pre>
+
+<!-- /insertSnippet -->`
+
+	document1, err := ParseDocument(Document{File: "source.go", Content: source})
+	assert.NoError(t, err)
+
+	document2, err := ParseDocument(Document{File: "target.html", Content: target})
+	assert.NoError(t, err)
+
+	documents, err := ReplaceSnippets([]ParsedDocument{document1, document2}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, targetReplaced, documents[1].Content)
+}
+
+func TestValidateDocumentsInvalidIndentationAttributes(t *testing.T) {
+
+	content := `lorem
+insertFile[file2 dedent=yolo indent=-1]
+/insertFile`
+
+	document1, err := ParseDocument(Document{File: "file1", Content: content})
+	assert.NoError(t, err)
+
+	document2, err := ParseDocument(Document{File: "file2", Content: "lorem ipsum"})
+	assert.NoError(t, err)
+
+	errors := ValidateDocuments([]ParsedDocument{document1, document2})
+	assert.Equal(t, 2, len(errors))
+	assert.Equal(t, "invalid boolean value 'yolo' for attribute 'dedent' in 'file1:2'", errors[0].Error())
+	assert.Equal(t, "invalid number '-1' for attribute 'indent' in 'file1:2'", errors[1].Error())
+}
+
+func TestReplaceSnippetsRemoveIndentBlankLines(t *testing.T) {
+
+	source := `snippet[id1]
+	snippet line 1
+
+		snippet line 2
+/snippet`
+
+	target := `insertSnippet[id1 indent=4]
+/insertSnippet`
+
+	targetReplaced := `insertSnippet[id1 indent=4]
+    snippet line 1
+
+    	snippet line 2
+/insertSnippet`
+
+	document1, err := ParseDocument(Document{File: "source", Content: source})
+	assert.NoError(t, err)
+
+	document2, err := ParseDocument(Document{File: "target", Content: target})
+	assert.NoError(t, err)
+
+	documents, err := ReplaceSnippets([]ParsedDocument{document1, document2}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, targetReplaced, documents[1].Content)
+}
+
+func TestReplaceFilesLineRange(t *testing.T) {
+
+	source := `yolo1
+yolo2
+yolo3
+yolo4`
+
+	target := `insertFile[source#L2-L3]
+/insertFile`
+
+	targetReplaced := `insertFile[source#L2-L3]
+yolo2
+yolo3
+/insertFile`
+
+	document1, err := ParseDocument(Document{File: "source", Content: source})
+	assert.NoError(t, err)
+
+	document2, err := ParseDocument(Document{File: "target", Content: target})
+	assert.NoError(t, err)
+
+	documents, err := ReplaceSnippets([]ParsedDocument{document1, document2}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, targetReplaced, documents[1].Content)
+}
+
+func TestReplaceFilesLineRangeOutOfBounds(t *testing.T) {
+
+	document1, err := ParseDocument(Document{File: "source", Content: "yolo1"})
+	assert.NoError(t, err)
+
+	document2, err := ParseDocument(Document{File: "target", Content: "insertFile[source#L2-L3]\n/insertFile"})
+	assert.NoError(t, err)
+
+	_, err = ReplaceSnippets([]ParsedDocument{document1, document2}, "")
+	assert.EqualError(t, err, "could not insert 'source#L2-L3' into 'target:1': line range 'L2-L3' is out of bounds, file has 1 lines")
+}
+
+func TestValidateDocumentsInsertFileMissing(t *testing.T) {
+
+	document, err := ParseDocument(Document{File: "file1", Content: "insertFile[file2]\n/insertFile"})
+	assert.NoError(t, err)
+
+	errors := ValidateDocuments([]ParsedDocument{document})
+	assert.Equal(t, 1, len(errors))
+	assert.Equal(t, "could not insert 'file2' into 'file1:1': file 'file2' not found", errors[0].Error())
+}
+
+func TestReplaceSnippetsInsertFileNotRead(t *testing.T) {
+	// files that were not read as documents are read from disk
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "small.txt"), []byte("small"), 0644))
+
+	document, err := ParseDocument(Document{File: filepath.Join(dir, "README.md"), Content: "insertFile[small.txt]\n/insertFile"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0, len(ValidateDocuments([]ParsedDocument{document})))
+
+	documents, err := ReplaceSnippets([]ParsedDocument{document}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "insertFile[small.txt]\n```\nsmall\n```\n\n/insertFile", documents[0].Content)
+}
+
+func TestValidateDocumentsInsertFileNoMatch(t *testing.T) {
+
+	document1, err := ParseDocument(Document{File: "file1", Content: "insertFile[config.yml from=\"^server:\" to=\"^\\S\"]\n/insertFile"})
+	assert.NoError(t, err)
+
+	document2, err := ParseDocument(Document{File: "config.yml", Content: "client:\n  port: 8080"})
+	assert.NoError(t, err)
+
+	errors := ValidateDocuments([]ParsedDocument{document1, document2})
+	assert.Equal(t, 1, len(errors))
+	assert.Equal(t, "could not insert 'config.yml' into 'file1:1': no line matching from='^server:' found", errors[0].Error())
+}
+
+func TestReplaceFilesExclusiveBounds(t *testing.T) {
+
+	source := `client:
+  port: 8080
+server:
+  port: 9090
+  host: localhost
+logging:
+  level: info`
+
+	target := `insertFile[config.yml from="^server:" to="^\S" bounds=exclusive dedent]
+/insertFile`
+
+	targetReplaced := `insertFile[config.yml from="^server:" to="^\S" bounds=exclusive dedent]
+port: 9090
+host: localhost
+/insertFile`
+
+	document1, err := ParseDocument(Document{File: "config.yml", Content: source})
+	assert.NoError(t, err)
+
+	document2, err := ParseDocument(Document{File: "target", Content: target})
+	assert.NoError(t, err)
+
+	errors := ValidateDocuments([]ParsedDocument{document1, document2})
+	assert.Equal(t, 0, len(errors))
+
+	documents, err := ReplaceSnippets([]ParsedDocument{document1, document2}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, targetReplaced, documents[1].Content)
+}
@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ProfileConfig configures the profiles that decide which conditional inserts are rendered
+type ProfileConfig struct {
+	// Active are the active profiles, unless profiles are set with '--profile'
+	Active []string `json:"active"`
+	// Placeholder is inserted instead of inserts whose conditions are not met, by default nothing is inserted
+	Placeholder string `json:"placeholder"`
+}
+
+var conditionTermExpression = regexp.MustCompile(`^!?[a-zA-Z0-9_\-.]+$`)
+
+// parseCondition splits a condition like 'enterprise,!linux' into its terms
+func parseCondition(condition string) ([]string, error) {
+	terms := strings.Split(condition, ",")
+	for _, term := range terms {
+		if !conditionTermExpression.MatchString(term) {
+			return nil, fmt.Errorf("invalid condition '%s'", condition)
+		}
+	}
+
+	return terms, nil
+}
+
+// isConditionMet reports whether one of the terms of condition holds for the active profiles, where a term 'name'
+// holds if the profile is active and '!name' if it is not. An empty condition is always met.
+func isConditionMet(condition string, profiles []string) (bool, error) {
+	if len(condition) == 0 {
+		return true, nil
+	}
+
+	terms, err := parseCondition(condition)
+	if err != nil {
+		return false, err
+	}
+
+	for _, term := range terms {
+		if strings.HasPrefix(term, "!") != contains(profiles, strings.TrimPrefix(term, "!")) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isInsertEnabled reports whether the 'if' condition of the insert marker and, for 'insertSnippet', the 'tags' of
+// the inserted snippet are met for the active profiles
+func isInsertEnabled(documents []ParsedDocument, marker *SnippetMarker, profiles []string) (bool, error) {
+	enabled, err := isConditionMet(marker.Attribute("if", ""), profiles)
+	if err != nil || !enabled || marker.Kind != KindInsertSnippet {
+		return enabled, err
+	}
+
+	snippet := getSnippetMarker(documents, marker.Id)
+	if snippet == nil {
+		return true, nil
+	}
+
+	return isConditionMet(snippet.Attribute("tags", ""), profiles)
+}
+
+// Validate checks that the placeholder does not contain markers, which would break the structure of the documents it
+// is inserted into the next time they are read
+func (config ProfileConfig) Validate() error {
+	for _, line := range config.placeholderLines() {
+		if ParseMarker(line) != nil {
+			return fmt.Errorf("placeholder '%s' must not contain markers", config.Placeholder)
+		}
+	}
+
+	return nil
+}
+
+// placeholderLines returns the lines inserted instead of an insert whose conditions are not met
+func (config ProfileConfig) placeholderLines() []string {
+	if len(config.Placeholder) == 0 {
+		return nil
+	}
+
+	return strings.Split(config.Placeholder, "\n")
+}
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+func TestIsConditionMet(t *testing.T) {
+	met, err := isConditionMet("", nil)
+	assert.NoError(t, err)
+	assert.True(t, met)
+
+	met, err = isConditionMet("enterprise", []string{"enterprise"})
+	assert.NoError(t, err)
+	assert.True(t, met)
+
+	met, err = isConditionMet("enterprise", []string{"community"})
+	assert.NoError(t, err)
+	assert.False(t, met)
+
+	met, err = isConditionMet("linux,darwin", []string{"darwin"})
+	assert.NoError(t, err)
+	assert.True(t, met)
+
+	met, err = isConditionMet("!enterprise", []string{"enterprise"})
+	assert.NoError(t, err)
+	assert.False(t, met)
+
+	met, err = isConditionMet("!enterprise", nil)
+	assert.NoError(t, err)
+	assert.True(t, met)
+
+	_, err = isConditionMet("enterprise,", nil)
+	assert.Error(t, err)
+}
+
+func profileDocumentsTest(t *testing.T) []ParsedDocument {
+	return parseDocumentsTest(t,
+		Document{File: "install.sh", Content: "# snippet[install-linux tags=linux]\napt install snex\n# /snippet\n# snippet[install-windows tags=windows]\nwinget install snex\n# /snippet"},
+		Document{File: "README.md", Content: "insertSnippet[install-linux]\n/insertSnippet\ninsertSnippet[install-windows]\n/insertSnippet\ninsertFile[install.sh#L2 if=!enterprise]\n/insertFile"},
+	)
+}
+
+func TestReplaceSnippetsProfiles(t *testing.T) {
+	config := Config{Profiles: ProfileConfig{Active: []string{"linux"}}}
+	replaced, err := ReplaceSnippetsWithConfig(profileDocumentsTest(t), "{{.Content}}", config)
+	assert.NoError(t, err)
+	assert.Equal(t, "insertSnippet[install-linux]\napt install snex\n/insertSnippet\ninsertSnippet[install-windows]\n/insertSnippet\ninsertFile[install.sh#L2 if=!enterprise]\napt install snex\n/insertFile", replaced[1].Content)
+
+	config = Config{Profiles: ProfileConfig{Active: []string{"windows", "enterprise"}, Placeholder: "not available"}}
+	replaced, err = ReplaceSnippetsWithConfig(profileDocumentsTest(t), "{{.Content}}", config)
+	assert.NoError(t, err)
+	assert.Equal(t, "insertSnippet[install-linux]\nnot available\n/insertSnippet\ninsertSnippet[install-windows]\nwinget install snex\n/insertSnippet\ninsertFile[install.sh#L2 if=!enterprise]\nnot available\n/insertFile", replaced[1].Content)
+}
+
+func TestReplaceSnippetsPlaceholderNotParsed(t *testing.T) {
+	documents := parseDocumentsTest(t,
+		Document{File: "install.sh", Content: "# snippet[install-linux tags=linux]\napt install snex\n# /snippet"},
+		Document{File: "README.md", Content: "insertSnippet[install-linux]\n/insertSnippet\ninsertSnippet[install-linux]\n/insertSnippet"},
+	)
+
+	config := Config{Profiles: ProfileConfig{Placeholder: "see /insertSnippet"}}
+	replaced, err := ReplaceSnippetsWithConfig(documents, "{{.Content}}", config)
+	assert.NoError(t, err)
+	assert.Equal(t, "insertSnippet[install-linux]\nsee /insertSnippet\n/insertSnippet\ninsertSnippet[install-linux]\nsee /insertSnippet\n/insertSnippet", replaced[1].Content)
+
+	assert.Error(t, config.Profiles.Validate())
+	assert.NoError(t, ProfileConfig{Placeholder: "not available"}.Validate())
+}
+
+func TestValidateConditions(t *testing.T) {
+	documents := parseDocumentsTest(t, Document{File: "README.md", Content: "first\ninsertFile[README.md if=a,,b]\n/insertFile"})
+
+	errors := validateAttributes(documents)
+	assert.Equal(t, 1, len(errors))
+	assert.EqualError(t, errors[0], "invalid condition 'a,,b' in 'README.md:2'")
+}
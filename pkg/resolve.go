@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// insertRegion is the content between an insert start marker and its end marker
+type insertRegion struct {
+	Document int
+	// Start is the index of the start marker line, End the index of the end marker line or the number of lines if
+	// the region is not closed
+	Start int
+	End   int
+	// Dependencies are the regions whose resolved content is read when this region is rendered
+	Dependencies []*insertRegion
+}
+
+// lineSpan is a range of lines inside of a document that an insert reads its content from
+type lineSpan struct {
+	Document int
+	From     int
+	To       int
+}
+
+func (region *insertRegion) String(documents []ParsedDocument) string {
+	document := documents[region.Document]
+	return fmt.Sprintf("%s:%d", document.File, document.Lines[region.Start].number+1)
+}
+
+// collectInsertRegions returns the outermost insert regions of all documents, start and end markers are paired by
+// depth, so markers of already inserted content that itself contains inserts are part of the region and replaced
+// with it
+func collectInsertRegions(documents []ParsedDocument) []*insertRegion {
+	var regions []*insertRegion
+
+	for documentIndex, document := range documents {
+		var region *insertRegion
+		depth := 0
+
+		for index, line := range document.Lines {
+			snippet := line.Snippet
+			if snippet == nil || !snippet.IsInsert() {
+				continue
+			}
+
+			if snippet.IsStart {
+				if depth == 0 {
+					region = &insertRegion{Document: documentIndex, Start: index, End: len(document.Lines)}
+				}
+				depth++
+			} else if snippet.IsEnd && depth > 0 {
+				depth--
+				if depth == 0 {
+					region.End = index
+					regions = append(regions, region)
+					region = nil
+				}
+			}
+		}
+
+		if region != nil {
+			regions = append(regions, region)
+		}
+	}
+
+	return regions
+}
+
+func documentSpan(documents []ParsedDocument, file string) []lineSpan {
+	for index := range documents {
+		if strings.HasSuffix(documents[index].File, file) {
+			return []lineSpan{{Document: index, From: 0, To: len(documents[index].Lines)}}
+		}
+	}
+
+	return nil
+}
+
+// insertSources returns the line spans the content of the insert marker is read from
+func insertSources(documents []ParsedDocument, marker *SnippetMarker) []lineSpan {
+	switch {
+	case marker.Kind == KindInsertSnippet:
+		for documentIndex, document := range documents {
+			for index, line := range document.Lines {
+				if line.Snippet == nil || line.Snippet.Kind != KindSnippet || !line.Snippet.IsStart || line.Snippet.Id != marker.Id {
+					continue
+				}
+
+				end := len(document.Lines)
+				for next := index + 1; next < len(document.Lines); next++ {
+					if document.Lines[next].Snippet != nil && document.Lines[next].Snippet.Kind == KindSnippet && document.Lines[next].Snippet.IsEnd {
+						end = next
+						break
+					}
+				}
+
+				return []lineSpan{{Document: documentIndex, From: index, To: end}}
+			}
+		}
+
+	case marker.Kind == KindInsertGoDoc || marker.Kind == KindInsertGoApi:
+		dir, _ := splitFileId(marker.Id)
+
+		var spans []lineSpan
+		for index, document := range documents {
+			if isGoPackageFile(document.File, dir) {
+				spans = append(spans, lineSpan{Document: index, From: 0, To: len(document.Lines)})
+			}
+		}
+		return spans
+
+	case marker.Kind == KindInsertGoExample:
+		dir, _ := splitFileId(marker.Id)
+
+		var spans []lineSpan
+		for index, document := range documents {
+			if isGoTestFile(document.File, dir) {
+				spans = append(spans, lineSpan{Document: index, From: 0, To: len(documents[index].Lines)})
+			}
+		}
+		return spans
+
+	case marker.Kind == KindInsertCommand || marker.Kind == KindInsertTree:
+		return nil
+
+	case marker.IsInsert():
+		file, _ := splitFileId(marker.Id)
+		return documentSpan(documents, file)
+	}
+
+	return nil
+}
+
+// resolveInsertOrder returns the insert regions of all documents in an order where every region comes after the
+// regions its content depends on, or an error with the full chain if the inserts depend on each other in a cycle
+func resolveInsertOrder(documents []ParsedDocument) ([]*insertRegion, error) {
+	regions := collectInsertRegions(documents)
+
+	for _, region := range regions {
+		for _, span := range insertSources(documents, documents[region.Document].Lines[region.Start].Snippet) {
+			for _, dependency := range regions {
+				if dependency.Document == span.Document && dependency.Start >= span.From && dependency.Start < span.To {
+					region.Dependencies = append(region.Dependencies, dependency)
+				}
+			}
+		}
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	states := make(map[*insertRegion]int)
+	var order []*insertRegion
+	var chain []*insertRegion
+
+	var visit func(region *insertRegion) error
+	visit = func(region *insertRegion) error {
+		switch states[region] {
+		case visited:
+			return nil
+		case visiting:
+			var cycle []string
+			for index := len(chain) - 1; index >= 0; index-- {
+				if chain[index] == region {
+					for _, member := range chain[index:] {
+						cycle = append(cycle, member.String(documents))
+					}
+					break
+				}
+			}
+
+			return fmt.Errorf("insert cycle detected: %s -> %s", strings.Join(cycle, " -> "), region.String(documents))
+		}
+
+		states[region] = visiting
+		chain = append(chain, region)
+
+		for _, dependency := range region.Dependencies {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+
+		chain = chain[:len(chain)-1]
+		states[region] = visited
+		order = append(order, region)
+
+		return nil
+	}
+
+	for _, region := range regions {
+		if err := visit(region); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+func validateInsertCycles(documents []ParsedDocument) []error {
+	if _, err := resolveInsertOrder(documents); err != nil {
+		return []error{err}
+	}
+
+	return []error{}
+}
@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+func parseDocumentsTest(t *testing.T, documents ...Document) []ParsedDocument {
+	var parsedDocuments []ParsedDocument
+	for _, document := range documents {
+		parsedDocument, err := ParseDocument(document)
+		assert.NoError(t, err)
+		parsedDocuments = append(parsedDocuments, parsedDocument)
+	}
+
+	return parsedDocuments
+}
+
+func TestReplaceSnippetsTransitiveFiles(t *testing.T) {
+	// README.md comes first but depends on docs/usage.md, which itself needs docs/install.md to be resolved first
+	documents := parseDocumentsTest(t,
+		Document{File: "README.md", Content: "insertFile[docs/usage.md]\n/insertFile"},
+		Document{File: "docs/usage.md", Content: "usage\ninsertFile[docs/install.md]\nstale\n/insertFile"},
+		Document{File: "docs/install.md", Content: "install"},
+	)
+
+	replaced, err := ReplaceSnippets(documents, "{{.Content}}")
+	assert.NoError(t, err)
+	assert.Equal(t, "insertFile[docs/usage.md]\nusage\ninsertFile[docs/install.md]\ninstall\n/insertFile\n/insertFile", replaced[0].Content)
+	assert.Equal(t, "usage\ninsertFile[docs/install.md]\ninstall\n/insertFile", replaced[1].Content)
+}
+
+func TestReplaceSnippetsTransitiveFilesTwice(t *testing.T) {
+	documents := parseDocumentsTest(t,
+		Document{File: "README.md", Content: "insertFile[docs/usage.md]\n/insertFile"},
+		Document{File: "docs/usage.md", Content: "usage\ninsertFile[docs/install.md]\nstale\n/insertFile"},
+		Document{File: "docs/install.md", Content: "install"},
+	)
+
+	first, err := ReplaceSnippets(documents, "{{.Content}}")
+	assert.NoError(t, err)
+
+	documents = parseDocumentsTest(t, first...)
+	assert.Equal(t, 0, len(ValidateDocuments(documents)))
+
+	second, err := ReplaceSnippets(documents, "{{.Content}}")
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestReplaceSnippetsTransitiveSnippets(t *testing.T) {
+	documents := parseDocumentsTest(t,
+		Document{File: "file1", Content: "insertSnippet[snippet2]\n/insertSnippet\ninsertSnippet[snippet2]\n/insertSnippet"},
+		Document{File: "file2", Content: "snippet[snippet2]\ninsertSnippet[snippet3]\n/insertSnippet\n/snippet"},
+		Document{File: "file3", Content: "snippet[snippet3]\nsnippet3\n/snippet"},
+	)
+
+	replaced, err := ReplaceSnippets(documents, "{{.Content}}")
+	assert.NoError(t, err)
+	assert.Equal(t, "insertSnippet[snippet2]\nsnippet3\n/insertSnippet\ninsertSnippet[snippet2]\nsnippet3\n/insertSnippet", replaced[0].Content)
+	assert.Equal(t, "snippet[snippet2]\ninsertSnippet[snippet3]\nsnippet3\n/insertSnippet\n/snippet", replaced[1].Content)
+}
+
+func TestResolveInsertOrderSnippetsInSameFileAreNoCycle(t *testing.T) {
+	// file-level dependencies in both directions, but the snippet regions do not depend on each other
+	documents := parseDocumentsTest(t,
+		Document{File: "file1", Content: "snippet[snippet1]\nsnippet1\n/snippet\ninsertSnippet[snippet2]\n/insertSnippet"},
+		Document{File: "file2", Content: "snippet[snippet2]\nsnippet2\n/snippet\ninsertSnippet[snippet1]\n/insertSnippet"},
+	)
+
+	_, err := resolveInsertOrder(documents)
+	assert.NoError(t, err)
+}
+
+func TestResolveInsertOrderCycle(t *testing.T) {
+	documents := parseDocumentsTest(t,
+		Document{File: "file1", Content: "lorem\ninsertFile[file2]\n/insertFile"},
+		Document{File: "file2", Content: "insertSnippet[snippet3]\n/insertSnippet"},
+		Document{File: "file3", Content: "snippet[snippet3]\ninsertFile[file1]\n/insertFile\n/snippet"},
+	)
+
+	_, err := resolveInsertOrder(documents)
+	assert.EqualError(t, err, "insert cycle detected: file1:2 -> file2:1 -> file3:2 -> file1:2")
+
+	_, err = ReplaceSnippets(documents, "")
+	assert.EqualError(t, err, "insert cycle detected: file1:2 -> file2:1 -> file3:2 -> file1:2")
+}
@@ -0,0 +1,166 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var markdownHeadingExpression = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+var asciiDocHeadingExpression = regexp.MustCompile(`^(={1,6})\s+(\S.*?)\s*$`)
+var markdownFenceExpression = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+var asciiDocBlockDelimiterExpression = regexp.MustCompile(`^(-{4,}|\.{4,}|/{4,}|\+{4,}|_{4,})\s*$`)
+var headingSlugExpression = regexp.MustCompile(`[^\p{L}\p{N}\s_-]`)
+
+// heading is a Markdown or AsciiDoc heading line inside of a document
+type heading struct {
+	Level int
+	Text  string
+}
+
+func isAsciiDocFile(file string) bool {
+	return matchAnyPattern([]string{"*.adoc", "*.asciidoc", "*.asc"}, file)
+}
+
+// headingSlug returns the anchor generated by GitHub for a heading, e.g. 'getting-started' for 'Getting Started'
+func headingSlug(text string) string {
+	return strings.ReplaceAll(headingSlugExpression.ReplaceAllString(strings.ToLower(strings.TrimSpace(text)), ""), " ", "-")
+}
+
+// findHeadings returns the heading for every line of a Markdown or AsciiDoc document, or nil if the line is not a
+// heading, skipping lines inside of fenced code blocks and AsciiDoc delimited blocks
+func findHeadings(lines []string, asciiDoc bool) []*heading {
+	headings := make([]*heading, len(lines))
+
+	fence := ""
+	for index, line := range lines {
+		if len(fence) > 0 {
+			if asciiDoc && strings.TrimSpace(line) == fence {
+				fence = ""
+			} else if !asciiDoc && strings.HasPrefix(strings.TrimSpace(line), fence) && len(strings.Trim(strings.TrimSpace(line), fence[:1])) == 0 {
+				fence = ""
+			}
+			continue
+		}
+
+		if asciiDoc {
+			if match := asciiDocBlockDelimiterExpression.FindStringSubmatch(line); match != nil {
+				fence = match[1]
+				continue
+			}
+
+			if match := asciiDocHeadingExpression.FindStringSubmatch(line); match != nil {
+				headings[index] = &heading{Level: len(match[1]), Text: match[2]}
+			}
+			continue
+		}
+
+		if match := markdownFenceExpression.FindStringSubmatch(line); match != nil {
+			fence = match[1]
+			continue
+		}
+
+		if match := markdownHeadingExpression.FindStringSubmatch(line); match != nil {
+			headings[index] = &heading{Level: len(match[1]), Text: match[2]}
+		}
+	}
+
+	return headings
+}
+
+// formatHeading renders a heading line for the given level, keeping the level between 1 and 6
+func formatHeading(text string, level int, asciiDoc bool) string {
+	if level < 1 {
+		level = 1
+	}
+	if level > 6 {
+		level = 6
+	}
+
+	if asciiDoc {
+		return strings.Repeat("=", level) + " " + text
+	}
+
+	return strings.Repeat("#", level) + " " + text
+}
+
+// extractSection returns the section of a Markdown or AsciiDoc document referenced like 'docs/install.md#Installation'
+// up to the next heading of the same or a higher level. The heading is matched by its text or by its GitHub style
+// anchor, the 'shift' attribute moves all heading levels and 'heading=false' drops the heading line itself.
+func extractSection(documents []ParsedDocument, marker *SnippetMarker) ([]string, error) {
+	file, name := splitFileId(marker.Id)
+
+	document := getDocumentForFile(documents, file)
+	if document == nil {
+		return nil, fmt.Errorf("file '%s' not found", file)
+	}
+
+	if len(name) == 0 {
+		return nil, fmt.Errorf("no heading specified, expected '%s#<heading>'", file)
+	}
+
+	shift, err := strconv.Atoi(marker.Attribute("shift", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid number '%s' for attribute 'shift'", marker.Attribute("shift", ""))
+	}
+
+	includeHeading, err := marker.BoolAttribute("heading", true)
+	if err != nil {
+		return nil, err
+	}
+
+	// marker lines of the source document are dropped, so the inserted section does not contain nested markers
+	var lines []string
+	for _, line := range document.Lines {
+		if line.Snippet == nil {
+			lines = append(lines, line.line)
+		}
+	}
+
+	asciiDoc := isAsciiDocFile(document.File)
+	headings := findHeadings(lines, asciiDoc)
+
+	start := -1
+	for index, heading := range headings {
+		if heading != nil && (heading.Text == name || headingSlug(heading.Text) == strings.ToLower(name)) {
+			start = index
+			break
+		}
+	}
+
+	if start == -1 {
+		return nil, fmt.Errorf("heading '%s' not found in '%s'", name, file)
+	}
+
+	end := len(lines)
+	for index := start + 1; index < len(lines); index++ {
+		if headings[index] != nil && headings[index].Level <= headings[start].Level {
+			end = index
+			break
+		}
+	}
+
+	var section []string
+	for index := start; index < end; index++ {
+		if index == start && !includeHeading {
+			continue
+		}
+
+		if headings[index] != nil && shift != 0 {
+			section = append(section, formatHeading(headings[index].Text, headings[index].Level+shift, asciiDoc))
+		} else {
+			section = append(section, lines[index])
+		}
+	}
+
+	for len(section) > 0 && isBlank(section[0]) {
+		section = section[1:]
+	}
+
+	for len(section) > 0 && isBlank(section[len(section)-1]) {
+		section = section[:len(section)-1]
+	}
+
+	return section, nil
+}
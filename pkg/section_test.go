@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+var installMarkdownSource = `# Project
+
+## Getting Started
+
+Some intro
+
+### Installation
+
+Download the binary
+
+` + "```shell" + `
+# not a heading
+curl -L https://example.org/snex
+` + "```" + `
+
+<!-- snippet[install-version] -->
+version 1.0
+<!-- /snippet -->
+
+#### Verify
+
+Run snex --version
+
+## Usage
+`
+
+var installAsciiDocSource = `= Project
+
+== Installation
+
+----
+== not a heading
+----
+
+=== Verify
+
+Run snex --version
+
+== Usage
+`
+
+func extractSectionTest(t *testing.T, file string, source string, marker string) ([]string, error) {
+	document, err := ParseDocument(Document{File: file, Content: source})
+	assert.NoError(t, err)
+
+	return extractSection([]ParsedDocument{document}, ParseMarker(marker))
+}
+
+func TestHeadingSlug(t *testing.T) {
+	assert.Equal(t, "getting-started", headingSlug("Getting Started"))
+	assert.Equal(t, "whats-new-in-v10", headingSlug("What's new in v1.0?"))
+}
+
+func TestExtractSectionMarkdown(t *testing.T) {
+	lines, err := extractSectionTest(t, "docs/install.md", installMarkdownSource, "insertSection[install.md#Installation]")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"### Installation",
+		"",
+		"Download the binary",
+		"",
+		"```shell",
+		"# not a heading",
+		"curl -L https://example.org/snex",
+		"```",
+		"",
+		"version 1.0",
+		"",
+		"#### Verify",
+		"",
+		"Run snex --version",
+	}, lines)
+}
+
+func TestExtractSectionMarkdownSlugShiftWithoutHeading(t *testing.T) {
+	lines, err := extractSectionTest(t, "docs/install.md", installMarkdownSource, "insertSection[install.md#getting-started shift=-1 heading=false]")
+	assert.NoError(t, err)
+	assert.Equal(t, "Some intro", lines[0])
+	assert.Equal(t, "## Installation", lines[2])
+	assert.Equal(t, "### Verify", lines[13])
+	assert.Equal(t, "Run snex --version", lines[len(lines)-1])
+}
+
+func TestExtractSectionAsciiDoc(t *testing.T) {
+	lines, err := extractSectionTest(t, "docs/install.adoc", installAsciiDocSource, "insertSection[install.adoc#Installation shift=1]")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"=== Installation",
+		"",
+		"----",
+		"== not a heading",
+		"----",
+		"",
+		"==== Verify",
+		"",
+		"Run snex --version",
+	}, lines)
+}
+
+func TestExtractSectionErrors(t *testing.T) {
+	_, err := extractSectionTest(t, "docs/install.md", installMarkdownSource, "insertSection[install.md#Uninstall]")
+	assert.EqualError(t, err, "heading 'Uninstall' not found in 'install.md'")
+
+	_, err = extractSectionTest(t, "docs/install.md", installMarkdownSource, "insertSection[install.md]")
+	assert.EqualError(t, err, "no heading specified, expected 'install.md#<heading>'")
+
+	_, err = extractSectionTest(t, "docs/install.md", installMarkdownSource, "insertSection[install.md#Installation shift=x]")
+	assert.EqualError(t, err, "invalid number 'x' for attribute 'shift'")
+}
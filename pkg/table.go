@@ -0,0 +1,146 @@
+package pkg
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+var tableFormats = []string{"markdown", "asciidoc", "html"}
+
+// tableColumn is a column selected via a column list like 'name:Display Name,limit', where the optional part after
+// the colon renames the column
+type tableColumn struct {
+	Key    string
+	Header string
+}
+
+func parseTableColumns(columns string) []tableColumn {
+	var result []tableColumn
+
+	for _, column := range strings.Split(columns, ",") {
+		column = strings.TrimSpace(column)
+		if len(column) == 0 {
+			continue
+		}
+
+		key, header := column, column
+		if index := strings.Index(column, ":"); index >= 0 {
+			key, header = strings.TrimSpace(column[:index]), strings.TrimSpace(column[index+1:])
+		}
+
+		result = append(result, tableColumn{Key: key, Header: header})
+	}
+
+	return result
+}
+
+func escapeMarkdownTableCell(cell string) string {
+	cell = strings.Join(strings.Fields(cell), " ")
+	return strings.ReplaceAll(cell, "|", "\\|")
+}
+
+// tableFormatForFile returns the table format for the target file, AsciiDoc and HTML for their file extensions and
+// Markdown otherwise
+func tableFormatForFile(file string) string {
+	switch {
+	case isAsciiDocFile(file):
+		return "asciidoc"
+	case matchAnyPattern([]string{"*.html", "*.htm"}, file):
+		return "html"
+	}
+
+	return "markdown"
+}
+
+// tableFormat returns the table format set by the 'format' attribute of the marker, or the format for the target file
+func tableFormat(marker *SnippetMarker, target string) (string, error) {
+	format := marker.Attribute("format", tableFormatForFile(target))
+	if !contains(tableFormats, format) {
+		return "", fmt.Errorf("unknown format '%s', available formats are: %s", format, strings.Join(tableFormats, ", "))
+	}
+
+	return format, nil
+}
+
+// renderTable renders a table in the given format, with escapeCells the cells are escaped so they can not break the
+// table structure, otherwise they are inserted as-is, e.g. to keep markup inside of cells
+func renderTable(format string, headers []string, rows [][]string, escapeCells bool) []string {
+	switch format {
+	case "asciidoc":
+		return renderAsciiDocTable(headers, rows, escapeCells)
+	case "html":
+		return renderHtmlTable(headers, rows, escapeCells)
+	}
+
+	return renderMarkdownTable(headers, rows, escapeCells)
+}
+
+// renderAsciiDocTable renders an AsciiDoc table with the headers as implicit header row
+func renderAsciiDocTable(headers []string, rows [][]string, escapeCells bool) []string {
+	renderRow := func(cells []string) string {
+		var result []string
+		for _, cell := range cells {
+			if escapeCells {
+				cell = strings.ReplaceAll(strings.Join(strings.Fields(cell), " "), "|", "\\|")
+			}
+			result = append(result, "| "+cell)
+		}
+		return strings.Join(result, " ")
+	}
+
+	lines := []string{"|===", renderRow(headers), ""}
+	for _, row := range rows {
+		lines = append(lines, renderRow(row))
+	}
+
+	return append(lines, "|===")
+}
+
+// renderHtmlTable renders an HTML table with a head for the headers
+func renderHtmlTable(headers []string, rows [][]string, escapeCells bool) []string {
+	renderRow := func(cells []string, tag string) string {
+		row := "    <tr>"
+		for _, cell := range cells {
+			if escapeCells {
+				cell = html.EscapeString(cell)
+			}
+			row += "<" + tag + ">" + cell + "</" + tag + ">"
+		}
+		return row + "</tr>"
+	}
+
+	lines := []string{"<table>", "  <thead>", renderRow(headers, "th"), "  </thead>", "  <tbody>"}
+	for _, row := range rows {
+		lines = append(lines, renderRow(row, "td"))
+	}
+
+	return append(lines, "  </tbody>", "</table>")
+}
+
+// renderMarkdownTable renders a Markdown table, with escapeCells pipes are escaped and newlines inside of cells are
+// replaced by spaces
+func renderMarkdownTable(headers []string, rows [][]string, escapeCells bool) []string {
+	renderRow := func(cells []string) string {
+		var escaped []string
+		for _, cell := range cells {
+			if escapeCells {
+				cell = escapeMarkdownTableCell(cell)
+			}
+			escaped = append(escaped, cell)
+		}
+		return "| " + strings.Join(escaped, " | ") + " |"
+	}
+
+	var separators []string
+	for range headers {
+		separators = append(separators, "---")
+	}
+
+	lines := []string{renderRow(headers), "|" + strings.Join(separators, "|") + "|"}
+	for _, row := range rows {
+		lines = append(lines, renderRow(row))
+	}
+
+	return lines
+}
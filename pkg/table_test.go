@@ -0,0 +1,34 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+func TestParseTableColumns(t *testing.T) {
+	assert.Equal(t, []tableColumn{{Key: "name", Header: "Display Name"}, {Key: "limit", Header: "limit"}}, parseTableColumns(" name:Display Name, limit,"))
+}
+
+func TestRenderMarkdownTable(t *testing.T) {
+	lines := renderMarkdownTable([]string{"Name", "Value"}, [][]string{{"a|b", "multi\nline"}, {"c", ""}}, true)
+	assert.Equal(t, []string{"| Name | Value |", "|---|---|", "| a\\|b | multi line |", "| c |  |"}, lines)
+}
+
+func TestRenderAsciiDocTable(t *testing.T) {
+	lines := renderTable("asciidoc", []string{"Name", "Value"}, [][]string{{"a|b", "1"}}, true)
+	assert.Equal(t, []string{"|===", "| Name | Value", "", "| a\\|b | 1", "|==="}, lines)
+}
+
+func TestRenderHtmlTable(t *testing.T) {
+	lines := renderTable("html", []string{"Name"}, [][]string{{"<b>"}}, true)
+	assert.Equal(t, []string{"<table>", "  <thead>", "    <tr><th>Name</th></tr>", "  </thead>", "  <tbody>", "    <tr><td>&lt;b&gt;</td></tr>", "  </tbody>", "</table>"}, lines)
+
+	lines = renderTable("html", []string{"Name"}, [][]string{{"<b>"}}, false)
+	assert.Equal(t, "    <tr><td><b></td></tr>", lines[5])
+}
+
+func TestTableFormatForFile(t *testing.T) {
+	assert.Equal(t, "markdown", tableFormatForFile("README.md"))
+	assert.Equal(t, "asciidoc", tableFormatForFile("docs/index.adoc"))
+	assert.Equal(t, "html", tableFormatForFile("site/index.html"))
+}
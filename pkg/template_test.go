@@ -6,35 +6,171 @@ import (
 )
 
 func TestExecuteTemplate(t *testing.T) {
-	snippets, err := executeTemplate("begin\n{{.Content}}\nend", []string{"line1", "line2"}, "file1")
+	snippets, err := executeTemplate("begin\n{{.Content}}\nend", []string{"line1", "line2"}, "file1", "none", nil)
 	assert.NoError(t, err)
 	assert.Equal(t, []string{"begin", "line1", "line2", "end"}, snippets)
 }
 
 func TestExecuteTemplateTrailingNewline(t *testing.T) {
-	snippets, err := executeTemplate("begin\n{{.Content}}\nend\n", []string{"line1", "line2"}, "file1")
+	snippets, err := executeTemplate("begin\n{{.Content}}\nend\n", []string{"line1", "line2"}, "file1", "none", nil)
 	assert.NoError(t, err)
 	assert.Equal(t, []string{"begin", "line1", "line2", "end", ""}, snippets)
 }
 
 func TestExecuteTemplateMarkdown(t *testing.T) {
-	snippets, err := executeTemplateWithDefault([]string{"line1", "line2"}, "test.md", "")
+	snippets, err := executeTemplateWithDefault([]string{"line1", "line2"}, "test.md", "source.go", "", "", nil, DefaultSnippetTemplates)
 	assert.NoError(t, err)
 	assert.Equal(t, []string{"```", "line1", "line2", "```", ""}, snippets)
 }
 
 func TestExecuteTemplateMarkdownUppercase(t *testing.T) {
-	snippets, err := executeTemplateWithDefault([]string{"line1", "line2"}, "test.MD", "")
+	snippets, err := executeTemplateWithDefault([]string{"line1", "line2"}, "test.MD", "source.go", "", "", nil, DefaultSnippetTemplates)
 	assert.NoError(t, err)
 	assert.Equal(t, []string{"```", "line1", "line2", "```", ""}, snippets)
 }
 
+func TestExecuteTemplateMarkdownFence(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"```go", "line1", "```"}, "test.md", "README.md", "", "", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"````", "```go", "line1", "```", "````", ""}, snippets)
+}
+
+func TestMarkdownFence(t *testing.T) {
+	assert.Equal(t, "```", markdownFence("line1"))
+	assert.Equal(t, "```", markdownFence("`inline` code"))
+	assert.Equal(t, "````", markdownFence("```\ncode\n```"))
+	assert.Equal(t, "``````", markdownFence("`````"))
+}
+
+func TestExecuteTemplateMdx(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"line1", "line2"}, "test.mdx", "source.go", "", "", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"```", "line1", "line2", "```", ""}, snippets)
+}
+
+func TestExecuteTemplateAsciiDoc(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"line1", "line2"}, "test.adoc", "source.go", "", "", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"[source,go]", "----", "line1", "line2", "----", ""}, snippets)
+}
+
+func TestExecuteTemplateAsciiDocUnknownLanguage(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"line1", "line2"}, "test.adoc", "source.yolo", "", "", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"[source]", "----", "line1", "line2", "----", ""}, snippets)
+}
+
+func TestExecuteTemplateAsciiDocDelimiter(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"----", "line1", "------"}, "test.adoc", "source.adoc", "", "", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"[source]", "-------", "----", "line1", "------", "-------", ""}, snippets)
+}
+
+func TestAsciiDocDelimiter(t *testing.T) {
+	assert.Equal(t, "----", asciiDocDelimiter("line1"))
+	assert.Equal(t, "----", asciiDocDelimiter("i--"))
+	assert.Equal(t, "-----", asciiDocDelimiter("line1\n----\nline2"))
+	assert.Equal(t, "------", asciiDocDelimiter("  -----  "))
+}
+
+func TestExecuteTemplateRestructuredText(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"line1", "", "\tline2"}, "test.rst", "source.py", "", "", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".. code-block:: python", "", "   line1", "", "   \tline2", "", ""}, snippets)
+}
+
+func TestExecuteTemplateHtml(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"if a < b && c > d {", "}"}, "test.html", "source.go", "", "", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"<pre><code class=\"language-go\">if a &lt; b &amp;&amp; c &gt; d {", "}</code></pre>", ""}, snippets)
+}
+
+func TestExecuteTemplateHtmlRawContent(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"<b>bold</b>"}, "test.html", "source.html", "<div>{{.RawContent}}</div>", "", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"<div><b>bold</b></div>"}, snippets)
+}
+
+func TestExecuteTemplateEscapeNone(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"<b>bold</b>"}, "test.html", "source.html", "", "none", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"<pre><code class=\"language-html\"><b>bold</b></code></pre>", ""}, snippets)
+}
+
+func TestExecuteTemplateXml(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"if a < b {", "}"}, "test.xml", "source.go", "", "", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"if a &lt; b {", "}"}, snippets)
+}
+
+func TestExecuteTemplateEscapeAttribute(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"println(\"a\")", "println(\"b\")"}, "test.yaml", "source.go", "", "json", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`println(\"a\")\nprintln(\"b\")`}, snippets)
+}
+
+func TestExecuteTemplateLatex(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"line1", "line2"}, "test.tex", "source.go", "", "", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"\\begin{lstlisting}", "line1", "line2", "\\end{lstlisting}", ""}, snippets)
+}
+
+func TestExecuteTemplateOrg(t *testing.T) {
+	snippets, err := executeTemplateWithDefault([]string{"line1", "line2"}, "test.org", "source.go", "", "", nil, DefaultSnippetTemplates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"#+begin_src go", "line1", "line2", "#+end_src", ""}, snippets)
+}
+
+func TestExecuteTemplateFilename(t *testing.T) {
+	snippets, err := executeTemplate("{{.Filename}} {{.Language}}", []string{"line1"}, "source.go", "none", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"source.go go"}, snippets)
+}
+
 func TestExecuteTemplateUnknownExtension(t *testing.T) {
-	snippets, err := executeTemplateWithDefault([]string{"line1", "line2"}, "test.yolo", "")
+	snippets, err := executeTemplateWithDefault([]string{"line1", "line2"}, "test.yolo", "source.go", "", "", nil, DefaultSnippetTemplates)
 	assert.NoError(t, err)
 	assert.Equal(t, []string{"line1", "line2"}, snippets)
 }
 
+func TestExecuteTemplateExtensionSuffix(t *testing.T) {
+	for _, file := range []string{"build.cmd", "notes.rmd", "cmd", "md"} {
+		snippets, err := executeTemplateWithDefault([]string{"line1", "line2"}, file, "source.go", "", "", nil, DefaultSnippetTemplates)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"line1", "line2"}, snippets, file)
+	}
+}
+
+func TestFindTemplate(t *testing.T) {
+	assert.Equal(t, "Markdown", FindTemplate("docs/README.md", DefaultSnippetTemplates).Name)
+	assert.Equal(t, "Markdown", FindTemplate("docs/api/index.markdown", DefaultSnippetTemplates).Name)
+	assert.Equal(t, "AsciiDoc", FindTemplate("docs/index.adoc", DefaultSnippetTemplates).Name)
+	assert.Zero(t, FindTemplate("build.cmd", DefaultSnippetTemplates))
+}
+
+func TestFindTemplatePriority(t *testing.T) {
+	templates := append([]SnippetTemplate{{Name: "Docs", Template: "{{.Content}}", Patterns: []string{"docs/**/*.markdown"}, Priority: 1}}, DefaultSnippetTemplates...)
+
+	assert.Equal(t, "Docs", FindTemplate("docs/api/index.markdown", templates).Name)
+	assert.Equal(t, "Markdown", FindTemplate("src/index.markdown", templates).Name)
+}
+
+func TestFindTemplateConfigured(t *testing.T) {
+	config := Config{Templates: []SnippetTemplate{{Name: "Plain", Template: "{{.Content}}", Patterns: []string{"*.md"}}}}
+
+	assert.Equal(t, "Plain", FindTemplate("README.md", config.SnippetTemplates()).Name)
+	assert.Equal(t, "AsciiDoc", FindTemplate("index.adoc", config.SnippetTemplates()).Name)
+	assert.Equal(t, "Markdown", FindTemplate("README.md", DefaultSnippetTemplates).Name)
+}
+
+func TestExecuteTemplateWithConfiguredTemplate(t *testing.T) {
+	templates := append([]SnippetTemplate{{Name: "Plain", Template: "start\n{{.Content}}\nend", Patterns: []string{"*.md"}}}, DefaultSnippetTemplates...)
+
+	snippets, err := executeTemplateWithDefault([]string{"line1"}, "test.md", "source.go", "", "", nil, templates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"start", "line1", "end"}, snippets)
+}
+
 func TestValidateTemplate(t *testing.T) {
 	err := ValidateTemplate("")
 	assert.NoError(t, err)
@@ -61,3 +197,55 @@ func TestRemoveIndentationNonWhitespace(t *testing.T) {
 func TestRemoveIndentationSingleLine(t *testing.T) {
 	assert.Equal(t, []string{"one space"}, removeIndentation([]string{" one space"}))
 }
+
+func TestRemoveIndentationBlankLines(t *testing.T) {
+	assert.Equal(t, []string{"line1", "", "\tline2", ""}, removeIndentation([]string{"\tline1", "", "\t\tline2", "  "}))
+}
+
+func TestRemoveIndentationMixedTabsAndSpaces(t *testing.T) {
+	assert.Equal(t, []string{"line1", " line2"}, removeIndentation([]string{"\t line1", "\t  line2"}))
+	assert.Equal(t, []string{"\tline1", "    line2"}, removeIndentation([]string{"\tline1", "    line2"}))
+}
+
+func TestExpandTabs(t *testing.T) {
+	assert.Equal(t, []string{"    line1", "  a b", "        line2"}, expandTabs([]string{"\tline1", "  a\tb", "\t\tline2"}, 4))
+}
+
+func TestFormatIndentationTabsToSpaces(t *testing.T) {
+	marker := ParseMarker("insertSnippet[id1 tabs-to-spaces=2]")
+	lines, err := formatIndentation([]string{"\tline1", "", "    line2"}, marker, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"line1", "", "  line2"}, lines)
+}
+
+func TestFormatIndentationTabsToSpacesDefaultWidth(t *testing.T) {
+	marker := ParseMarker("insertSnippet[id1 tabs-to-spaces dedent=false]")
+	lines, err := formatIndentation([]string{"\tline1", "\t\tline2"}, marker, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"    line1", "        line2"}, lines)
+}
+
+func TestFormatIndentationTabsToSpacesZeroWidth(t *testing.T) {
+	marker := ParseMarker("insertSnippet[id1 tabs-to-spaces=0]")
+	_, err := formatIndentation([]string{"\tline1"}, marker, true)
+	assert.EqualError(t, err, "invalid tab width '0' for attribute 'tabs-to-spaces', the width must be at least 1")
+
+	document, err := ParseDocument(Document{File: "README.md", Content: "insertSnippet[id1 tabs-to-spaces=0]\n/insertSnippet"})
+	assert.NoError(t, err)
+	errors := validateAttributes([]ParsedDocument{document})
+	assert.Equal(t, 1, len(errors))
+	assert.EqualError(t, errors[0], "invalid tab width '0' for attribute 'tabs-to-spaces', the width must be at least 1 in 'README.md:1'")
+}
+
+func TestFormatIndentationIndent(t *testing.T) {
+	marker := ParseMarker("insertFile[file1 dedent indent=2]")
+	lines, err := formatIndentation([]string{"\tline1", "", "\t\tline2"}, marker, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"  line1", "", "  \tline2"}, lines)
+}
+
+func TestFormatIndentationInvalidIndent(t *testing.T) {
+	marker := ParseMarker("insertFile[file1 indent=yolo]")
+	_, err := formatIndentation([]string{"line1"}, marker, false)
+	assert.EqualError(t, err, "invalid number 'yolo' for attribute 'indent'")
+}
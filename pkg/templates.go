@@ -2,36 +2,148 @@ package pkg
 
 import (
 	"bytes"
-	"sort"
+	"fmt"
+	"path/filepath"
 	"strings"
 	template2 "text/template"
 )
 
 type SnippetTemplateData struct {
-	Content  string
-	Filename string
+	Content    string
+	RawContent string
+	Filename   string
+	Language   string
+	Fence      string
+	Delimiter  string
+	Vars       map[string]string
 }
 
-var TemplateHelp = "\t\t{{.Content}}\t\t snippet content\n" +
-	"\t\t{{.Filename}}\t\t the file the snippet content originated from\n"
+var TemplateHelp = "\t\t{{.Content}}\t\t snippet content, escaped for the target file\n" +
+	"\t\t{{.RawContent}}\t\t snippet content without any escaping\n" +
+	"\t\t{{.Filename}}\t\t the file the snippet content originated from\n" +
+	"\t\t{{.Language}}\t\t language of the snippet content derived from the file extension, e.g. 'go'\n" +
+	"\t\t{{.Fence}}\t\t markdown code fence that is longer than any backtick sequence inside the snippet content\n" +
+	"\t\t{{.Delimiter}}\t\t asciidoc listing delimiter that is longer than any delimiter line inside the snippet content\n" +
+	"\t\t{{.Vars.name}}\t\t value of the variable 'name', see '--var'\n" +
+	"\t\t{{indent 3 .Content}}\t snippet content indented by 3 spaces\n"
 
+// SnippetTemplate is used for all target files matching one of its Patterns, see matchPattern for the pattern
+// syntax. If more than one template matches, the one with the highest Priority wins.
 type SnippetTemplate struct {
-	Template   string
-	Extensions []string
+	Name     string   `json:"name"`
+	Template string   `json:"template"`
+	Patterns []string `json:"patterns"`
+	Priority int      `json:"priority"`
 }
 
 var DefaultSnippetTemplates = []SnippetTemplate{
-	{Template: "```\n{{.Content}}\n```\n", Extensions: []string{"md"}},
+	{Name: "Markdown", Template: "{{.Fence}}\n{{.Content}}\n{{.Fence}}\n", Patterns: []string{"*.md", "*.markdown"}},
+	{Name: "MDX", Template: "{{.Fence}}\n{{.Content}}\n{{.Fence}}\n", Patterns: []string{"*.mdx"}},
+	{Name: "AsciiDoc", Template: "[source{{if .Language}},{{.Language}}{{end}}]\n{{.Delimiter}}\n{{.Content}}\n{{.Delimiter}}\n", Patterns: []string{"*.adoc", "*.asciidoc"}},
+	{Name: "reStructuredText", Template: ".. code-block::{{if .Language}} {{.Language}}{{end}}\n\n{{indent 3 .Content}}\n\n", Patterns: []string{"*.rst"}},
+	{Name: "HTML", Template: "<pre><code{{if .Language}} class=\"language-{{.Language}}\"{{end}}>{{.Content}}</code></pre>\n", Patterns: []string{"*.html", "*.htm"}},
+	{Name: "LaTeX", Template: "\\begin{lstlisting}\n{{.Content}}\n\\end{lstlisting}\n", Patterns: []string{"*.tex"}},
+	{Name: "Org", Template: "#+begin_src{{if .Language}} {{.Language}}{{end}}\n{{.Content}}\n#+end_src\n", Patterns: []string{"*.org"}},
 }
 
-func executeTemplate(template string, snippet []string, file string) ([]string, error) {
+var languages = map[string]string{
+	"c":    "c",
+	"cpp":  "cpp",
+	"cs":   "csharp",
+	"css":  "css",
+	"go":   "go",
+	"h":    "c",
+	"hcl":  "hcl",
+	"html": "html",
+	"java": "java",
+	"js":   "javascript",
+	"json": "json",
+	"kt":   "kotlin",
+	"php":  "php",
+	"py":   "python",
+	"rb":   "ruby",
+	"rs":   "rust",
+	"sh":   "shell",
+	"sql":  "sql",
+	"tf":   "hcl",
+	"toml": "toml",
+	"ts":   "typescript",
+	"xml":  "xml",
+	"yaml": "yaml",
+	"yml":  "yaml",
+}
+
+var templateFunctions = template2.FuncMap{
+	"indent": indent,
+}
+
+func indent(spaces int, content string) string {
+	lines := strings.Split(content, "\n")
+	for index, line := range lines {
+		if len(strings.TrimSpace(line)) > 0 {
+			lines[index] = strings.Repeat(" ", spaces) + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// markdownFence returns a code fence that is longer than the longest sequence of backticks inside of content, so
+// the content can never close the fence
+func markdownFence(content string) string {
+	longest := 0
+	current := 0
+	for _, c := range content {
+		if c == '`' {
+			current++
+			if current > longest {
+				longest = current
+			}
+		} else {
+			current = 0
+		}
+	}
+
+	if longest < 3 {
+		return "```"
+	}
+
+	return strings.Repeat("`", longest+1)
+}
+
+// asciiDocDelimiter returns a listing block delimiter that is longer than any line of content only consisting of
+// dashes, so the content can never close the listing block
+func asciiDocDelimiter(content string) string {
+	longest := 0
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) > longest && strings.Trim(line, "-") == "" {
+			longest = len(line)
+		}
+	}
+
+	if longest < 4 {
+		return "----"
+	}
+
+	return strings.Repeat("-", longest+1)
+}
+
+func languageForFile(file string) string {
+	return languages[strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")]
+}
+
+func executeTemplate(template string, snippet []string, file string, escape string, vars map[string]string) ([]string, error) {
 	template = strings.ReplaceAll(template, "\\n", "\n")
-	tmpl, err := template2.New("snippet").Parse(template)
+	// undefined variables are errors like for '${{ snex.name }}' placeholders instead of rendering '<no value>'
+	tmpl, err := template2.New("snippet").Funcs(templateFunctions).Option("missingkey=error").Parse(template)
 	if err != nil {
 		return nil, err
 	}
 
-	templateData := SnippetTemplateData{Content: strings.Join(snippet, "\n"), Filename: file}
+	rawContent := strings.Join(snippet, "\n")
+	content := escapeContent(rawContent, escape)
+	templateData := SnippetTemplateData{Content: content, RawContent: rawContent, Filename: file, Language: languageForFile(file), Fence: markdownFence(content), Delimiter: asciiDocDelimiter(content), Vars: vars}
 
 	renderedTemplate := new(bytes.Buffer)
 	err = tmpl.Execute(renderedTemplate, templateData)
@@ -43,7 +155,7 @@ func executeTemplate(template string, snippet []string, file string) ([]string,
 }
 
 func ValidateTemplate(template string) error {
-	tmpl, err := template2.New("snippet").Parse(template)
+	tmpl, err := template2.New("snippet").Funcs(templateFunctions).Parse(template)
 	if err != nil {
 		return err
 	}
@@ -59,53 +171,183 @@ func ValidateTemplate(template string) error {
 	return nil
 }
 
-func executeTemplateWithDefault(lines []string, file string, template string) ([]string, error) {
+func executeTemplateWithDefault(lines []string, file string, source string, template string, escape string, vars map[string]string, templates []SnippetTemplate) ([]string, error) {
+	if len(escape) == 0 {
+		escape = escapeForFile(file)
+	}
+
 	if len(template) > 0 {
-		return executeTemplate(template, lines, file)
+		return executeTemplate(template, lines, source, escape, vars)
 	}
 
-	for _, template := range DefaultSnippetTemplates {
-		for _, extension := range template.Extensions {
-			if strings.HasSuffix(strings.ToLower(file), extension) {
-				return executeTemplate(template.Template, lines, file)
-			}
+	defaultTemplate := FindTemplate(file, templates)
+	if defaultTemplate != nil {
+		return executeTemplate(defaultTemplate.Template, lines, source, escape, vars)
+	}
+
+	if escape == "none" {
+		return lines, nil
+	}
+
+	return strings.Split(escapeContent(strings.Join(lines, "\n"), escape), "\n"), nil
+}
+
+// FindTemplate returns the template out of templates that will be used for replacements inside of file or nil if
+// no template matches, on equal priority the first matching template wins
+func FindTemplate(file string, templates []SnippetTemplate) *SnippetTemplate {
+	var result *SnippetTemplate
+
+	for index, template := range templates {
+		if matchAnyPattern(template.Patterns, file) && (result == nil || template.Priority > result.Priority) {
+			result = &templates[index]
 		}
 	}
 
-	return lines, nil
+	return result
 }
 
-func longestCommonPrefix(originalLines []string) string {
-	var longestPrefix = ""
+// Validate checks that the template can be rendered and matches at least one file pattern
+func (template SnippetTemplate) Validate() error {
+	if len(template.Name) == 0 {
+		return fmt.Errorf("template without name")
+	}
 
-	lines := make([]string, len(originalLines))
-	copy(lines, originalLines)
+	if len(template.Patterns) == 0 {
+		return fmt.Errorf("template '%s' has no file patterns", template.Name)
+	}
 
-	if len(lines) > 0 {
-		sort.Strings(lines)
+	if err := ValidateTemplate(template.Template); err != nil {
+		return fmt.Errorf("invalid template '%s': %s", template.Name, err)
+	}
 
-		firstLine := lines[0]
-		lastLine := lines[len(lines)-1]
+	return nil
+}
 
-		for i := 0; i < len(firstLine); i++ {
+// longestCommonPrefix returns the longest whitespace prefix shared by all lines, lines only consisting of
+// whitespace are ignored
+func longestCommonPrefix(lines []string) string {
+	var longestPrefix *string
 
-			if (string(lastLine[i]) == " " || string(lastLine[i]) == "\t") && string(lastLine[i]) == string(firstLine[i]) {
-				longestPrefix += string(lastLine[i])
-			} else {
-				return longestPrefix
-			}
+	for _, line := range lines {
+		if isBlank(line) {
+			continue
+		}
+
+		indentation := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if longestPrefix == nil {
+			longestPrefix = &indentation
+			continue
+		}
+
+		i := 0
+		for i < len(*longestPrefix) && i < len(indentation) && (*longestPrefix)[i] == indentation[i] {
+			i++
 		}
+		prefix := indentation[:i]
+		longestPrefix = &prefix
+	}
+
+	if longestPrefix == nil {
+		return ""
 	}
 
-	return longestPrefix
+	return *longestPrefix
+}
+
+func isBlank(line string) bool {
+	return len(strings.TrimSpace(line)) == 0
 }
 
 func removeIndentation(lines []string) []string {
 	prefix := longestCommonPrefix(lines)
 
 	for index, line := range lines {
-		lines[index] = strings.TrimPrefix(line, prefix)
+		if isBlank(line) {
+			lines[index] = ""
+		} else {
+			lines[index] = strings.TrimPrefix(line, prefix)
+		}
+	}
+
+	return lines
+}
+
+// expandTabs replaces all tabs with spaces up to the next tab stop, where tab stops are tabWidth columns apart
+func expandTabs(lines []string, tabWidth int) []string {
+	for index, line := range lines {
+		if !strings.Contains(line, "\t") {
+			continue
+		}
+
+		var expanded strings.Builder
+		column := 0
+		for _, c := range line {
+			if c == '\t' {
+				spaces := tabWidth - column%tabWidth
+				expanded.WriteString(strings.Repeat(" ", spaces))
+				column += spaces
+			} else {
+				expanded.WriteRune(c)
+				column++
+			}
+		}
+		lines[index] = expanded.String()
+	}
+
+	return lines
+}
+
+func addIndentation(lines []string, spaces int) []string {
+	for index, line := range lines {
+		if !isBlank(line) {
+			lines[index] = strings.Repeat(" ", spaces) + line
+		}
 	}
 
 	return lines
 }
+
+var defaultTabWidth = 4
+
+// tabWidth returns the tab width set by the 'tabs-to-spaces' marker attribute, or defaultTabWidth if the attribute
+// has no value
+func tabWidth(marker *SnippetMarker) (int, error) {
+	width, err := marker.IntAttribute("tabs-to-spaces", defaultTabWidth)
+	if err != nil {
+		return 0, err
+	}
+
+	if width < 1 {
+		return 0, fmt.Errorf("invalid tab width '%d' for attribute 'tabs-to-spaces', the width must be at least 1", width)
+	}
+
+	return width, nil
+}
+
+// formatIndentation applies the indentation related marker attributes 'tabs-to-spaces[=width]', 'dedent[=true|false]'
+// and 'indent=spaces' to lines, dedent defaults to dedentDefault if not set
+func formatIndentation(lines []string, marker *SnippetMarker, dedentDefault bool) ([]string, error) {
+	if _, ok := marker.Attributes["tabs-to-spaces"]; ok {
+		width, err := tabWidth(marker)
+		if err != nil {
+			return nil, err
+		}
+		lines = expandTabs(lines, width)
+	}
+
+	dedent, err := marker.BoolAttribute("dedent", dedentDefault)
+	if err != nil {
+		return nil, err
+	}
+
+	if dedent {
+		lines = removeIndentation(lines)
+	}
+
+	indent, err := marker.IntAttribute("indent", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return addIndentation(lines, indent), nil
+}
@@ -0,0 +1,171 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TreeConfig configures 'insertTree'
+type TreeConfig struct {
+	// Annotations maps paths or glob patterns relative to the document to a comment shown next to the entry
+	Annotations map[string]string `json:"annotations"`
+}
+
+type treeEntry struct {
+	Name     string
+	Path     string
+	IsDir    bool
+	Children []*treeEntry
+}
+
+// readTree reads the entries below dir up to depth levels (0 means unlimited), skipping ignored directories and
+// entries that do not match the include and exclude patterns. Directories without any included files are removed
+// if include patterns are given.
+func readTree(dir string, relative string, depth int, include []string, exclude []string, cache CacheConfig) ([]*treeEntry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*treeEntry
+	for _, file := range files {
+		path := filepath.ToSlash(filepath.Join(relative, file.Name()))
+		if (file.IsDir() && cache.IsIgnoredDir(filepath.Join(dir, file.Name()))) || matchAnyPattern(exclude, path) {
+			continue
+		}
+
+		entry := &treeEntry{Name: file.Name(), Path: path, IsDir: file.IsDir()}
+
+		if file.IsDir() {
+			if depth != 1 {
+				entry.Children, err = readTree(filepath.Join(dir, file.Name()), path, depth-1, include, exclude, cache)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if len(include) > 0 && len(entry.Children) == 0 {
+				continue
+			}
+		} else if len(include) > 0 && !matchAnyPattern(include, path) {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func sortTree(entries []*treeEntry, dirsFirst bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if dirsFirst && entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+
+	for _, entry := range entries {
+		sortTree(entry.Children, dirsFirst)
+	}
+}
+
+func treeAnnotation(annotations map[string]string, path string) string {
+	if annotation, ok := annotations[path]; ok {
+		return annotation
+	}
+
+	var patterns []string
+	for pattern := range annotations {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		if matchPattern(strings.TrimSuffix(pattern, "/"), path) {
+			return annotations[pattern]
+		}
+	}
+
+	return ""
+}
+
+// renderTree renders the entries in the style of the 'tree' command, directories get a trailing '/'
+func renderTree(entries []*treeEntry, prefix string, annotations map[string]string, base string) ([]string, []string) {
+	var lines []string
+	var comments []string
+
+	for index, entry := range entries {
+		connector, childPrefix := "├── ", "│   "
+		if index == len(entries)-1 {
+			connector, childPrefix = "└── ", "    "
+		}
+
+		name := entry.Name
+		if entry.IsDir {
+			name += "/"
+		}
+
+		lines = append(lines, prefix+connector+name)
+		comments = append(comments, treeAnnotation(annotations, filepath.ToSlash(filepath.Join(base, entry.Path))))
+
+		childLines, childComments := renderTree(entry.Children, prefix+childPrefix, annotations, base)
+		lines = append(lines, childLines...)
+		comments = append(comments, childComments...)
+	}
+
+	return lines, comments
+}
+
+// extractTree returns a 'tree' style listing of the directory referenced by an 'insertTree' marker, relative to the
+// directory of the target document. The attribute 'deep' limits the depth, 'include' and 'exclude' filter entries
+// with comma separated glob patterns and 'dirs-first=false' sorts directories and files together.
+func extractTree(config TreeConfig, cache CacheConfig, marker *SnippetMarker, target string) ([]string, error) {
+	depth, err := marker.IntAttribute("deep", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	dirsFirst, err := marker.BoolAttribute("dirs-first", true)
+	if err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(filepath.ToSlash(filepath.Clean(marker.Id)), "/")
+	dir := filepath.Join(filepath.Dir(target), filepath.FromSlash(base))
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("directory '%s' not found", marker.Id)
+	}
+
+	entries, err := readTree(dir, "", depth, parseInputPatterns(marker.Attribute("include", "")), parseInputPatterns(marker.Attribute("exclude", "")), cache)
+	if err != nil {
+		return nil, err
+	}
+	sortTree(entries, dirsFirst)
+
+	lines, comments := renderTree(entries, "", config.Annotations, base)
+
+	width := 0
+	for index, line := range lines {
+		if len(comments[index]) > 0 && len([]rune(line)) > width {
+			width = len([]rune(line))
+		}
+	}
+
+	for index, comment := range comments {
+		if len(comment) > 0 {
+			lines[index] += strings.Repeat(" ", width-len([]rune(lines[index]))) + "  # " + comment
+		}
+	}
+
+	root := base + "/"
+	if base == "." {
+		root = "./"
+	}
+
+	return append([]string{root}, lines...), nil
+}
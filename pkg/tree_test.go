@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTreeTest(t *testing.T, files ...string) string {
+	dir := t.TempDir()
+	for _, file := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o700))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(file), 0o600))
+	}
+
+	return dir
+}
+
+func extractTreeTest(t *testing.T, config TreeConfig, marker string) []string {
+	dir := writeTreeTest(t,
+		"examples/b.md",
+		"examples/hello/main.go",
+		"examples/hello/README.md",
+		"examples/world/main.go",
+		"examples/world/data/input.json",
+		"examples/.git/config",
+		"examples/.snex/cache/1234",
+		"examples/A.txt",
+	)
+
+	lines, err := extractTree(config, CacheConfig{}, ParseMarker(marker), filepath.Join(dir, "README.md"))
+	assert.NoError(t, err)
+
+	return lines
+}
+
+func TestExtractTree(t *testing.T) {
+	assert.Equal(t, []string{
+		"examples/",
+		"├── hello/",
+		"│   ├── main.go",
+		"│   └── README.md",
+		"├── world/",
+		"│   ├── data/",
+		"│   │   └── input.json",
+		"│   └── main.go",
+		"├── A.txt",
+		"└── b.md",
+	}, extractTreeTest(t, TreeConfig{}, "insertTree[examples/]"))
+}
+
+func TestExtractTreeDepthAndSorting(t *testing.T) {
+	assert.Equal(t, []string{
+		"examples/",
+		"├── A.txt",
+		"├── b.md",
+		"├── hello/",
+		"└── world/",
+	}, extractTreeTest(t, TreeConfig{}, "insertTree[examples deep=1 dirs-first=false]"))
+}
+
+func TestExtractTreeFilters(t *testing.T) {
+	assert.Equal(t, []string{
+		"examples/",
+		"├── hello/",
+		"│   └── main.go",
+		"└── world/",
+		"    └── main.go",
+	}, extractTreeTest(t, TreeConfig{}, "insertTree[examples/ include=*.go]"))
+
+	assert.Equal(t, []string{
+		"examples/",
+		"├── hello/",
+		"│   └── main.go",
+		"└── A.txt",
+	}, extractTreeTest(t, TreeConfig{}, "insertTree[examples/ exclude=world,*.md]"))
+}
+
+func TestExtractTreeAnnotations(t *testing.T) {
+	config := TreeConfig{Annotations: map[string]string{"examples/hello": "minimal example", "examples/**/main.go": "entrypoint"}}
+
+	assert.Equal(t, []string{
+		"examples/",
+		"├── hello/       # minimal example",
+		"│   └── main.go  # entrypoint",
+		"└── world/",
+		"    ├── data/",
+		"    │   └── input.json",
+		"    └── main.go  # entrypoint",
+	}, extractTreeTest(t, config, "insertTree[examples/ include=*.go,*.json]"))
+}
+
+func TestExtractTreeMissing(t *testing.T) {
+	_, err := extractTree(TreeConfig{}, CacheConfig{}, ParseMarker("insertTree[missing/]"), filepath.Join(t.TempDir(), "README.md"))
+	assert.EqualError(t, err, "directory 'missing/' not found")
+}
@@ -0,0 +1,343 @@
+package pkg
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"golang.org/x/mod/modfile"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValueSource defines where the value for inline value markers like '<!--v:version-->1.2.3<!--/v-->' comes from,
+// exactly one of the sources has to be set
+type ValueSource struct {
+	// File is the file Regex is applied to, the value is the first capture group or the whole match
+	File  string `json:"file"`
+	Regex string `json:"regex"`
+	// GoConst is a Go constant or variable with a literal value like 'pkg/version.go#Version'
+	GoConst string `json:"go-const"`
+	// GoMod is 'module' for the module path, 'go' for the Go version or the path of a required module for its version
+	GoMod string `json:"go-mod"`
+	// GitTag is a glob pattern like 'v*' or '*', the value is the latest local git tag matching it
+	GitTag string `json:"git-tag"`
+}
+
+// Validate checks that exactly one source is set and that the regular expression compiles
+func (source ValueSource) Validate() error {
+	count := 0
+	for _, value := range []string{source.File, source.GoConst, source.GoMod, source.GitTag} {
+		if len(value) > 0 {
+			count++
+		}
+	}
+
+	if count != 1 {
+		return fmt.Errorf("exactly one of 'file', 'go-const', 'go-mod' or 'git-tag' has to be set")
+	}
+
+	if len(source.File) > 0 {
+		if len(source.Regex) == 0 {
+			return fmt.Errorf("'file' needs a 'regex'")
+		}
+
+		if _, err := regexp.Compile(source.Regex); err != nil {
+			return fmt.Errorf("invalid regex '%s': %s", source.Regex, err)
+		}
+	}
+
+	return nil
+}
+
+// fileValue returns the first capture group, or the whole match, of the first match of expression in file
+func fileValue(documents []ParsedDocument, file string, expression string) (string, error) {
+	if getDocumentForFile(documents, file) == nil {
+		return "", fmt.Errorf("file '%s' not found", file)
+	}
+
+	compiled, err := regexp.Compile("(?m)" + expression)
+	if err != nil {
+		return "", err
+	}
+
+	match := compiled.FindStringSubmatch(strings.Join(getContentForFile(documents, file), "\n"))
+	if match == nil {
+		return "", fmt.Errorf("regex '%s' does not match '%s'", expression, file)
+	}
+
+	if len(match) > 1 {
+		return match[1], nil
+	}
+
+	return match[0], nil
+}
+
+// goConstValue returns the literal value of a Go constant or variable referenced like 'pkg/version.go#Version'
+func goConstValue(documents []ParsedDocument, id string) (string, error) {
+	file, name := splitFileId(id)
+
+	document := getDocumentForFile(documents, file)
+	if document == nil {
+		return "", fmt.Errorf("file '%s' not found", file)
+	}
+
+	_, parsedFile, err := parseGoFile(document.File, strings.Join(getContentForFile(documents, file), "\n"))
+	if err != nil {
+		return "", err
+	}
+
+	for _, decl := range parsedFile.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || (genDecl.Tok != token.CONST && genDecl.Tok != token.VAR) {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			valueSpec := spec.(*ast.ValueSpec)
+			for index, specName := range valueSpec.Names {
+				if specName.Name != name {
+					continue
+				}
+
+				if index >= len(valueSpec.Values) {
+					return "", fmt.Errorf("'%s' has no value", name)
+				}
+
+				literal, ok := valueSpec.Values[index].(*ast.BasicLit)
+				if !ok {
+					return "", fmt.Errorf("value of '%s' is not a literal", name)
+				}
+
+				if literal.Kind == token.STRING {
+					return strconv.Unquote(literal.Value)
+				}
+
+				return literal.Value, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("constant '%s' not found in '%s'", name, file)
+}
+
+// nearestGoMod returns the name and content of the go.mod file in dir or the nearest parent directory of dir, go.mod
+// files that were not read as documents are read from disk
+func nearestGoMod(documents []ParsedDocument, dir string) (string, []byte, error) {
+	for current := filepath.Clean(dir); ; current = filepath.Dir(current) {
+		file := filepath.Join(current, "go.mod")
+
+		for _, document := range documents {
+			if filepath.Clean(document.File) == file {
+				var lines []string
+				for _, line := range document.Lines {
+					lines = append(lines, line.line)
+				}
+				return file, []byte(strings.Join(lines, "\n")), nil
+			}
+		}
+
+		if content, err := os.ReadFile(file); err == nil {
+			return file, content, nil
+		}
+
+		if filepath.Dir(current) == current {
+			return "", nil, fmt.Errorf("no 'go.mod' found in '%s' or its parent directories", dir)
+		}
+	}
+}
+
+// goModValue returns the module path for 'module', the Go version for 'go' or the version of a required module from
+// the go.mod file nearest to dir
+func goModValue(documents []ParsedDocument, key string, dir string) (string, error) {
+	file, content, err := nearestGoMod(documents, dir)
+	if err != nil {
+		return "", err
+	}
+
+	goMod, err := modfile.ParseLax(file, content, nil)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case key == "module" && goMod.Module != nil:
+		return goMod.Module.Mod.Path, nil
+	case key == "go" && goMod.Go != nil:
+		return goMod.Go.Version, nil
+	}
+
+	for _, require := range goMod.Require {
+		if require.Mod.Path == key {
+			return require.Mod.Version, nil
+		}
+	}
+
+	return "", fmt.Errorf("'%s' not found in '%s'", key, file)
+}
+
+// gitTagValue returns the latest git tag matching pattern that is reachable from the current commit of the
+// repository containing dir
+func gitTagValue(dir string, pattern string) (string, error) {
+	lines, err := runProcess([]string{"git", "describe", "--tags", "--abbrev=0", "--match", pattern}, dir, "", commandEnv(nil), &SnippetMarker{})
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "")), nil
+}
+
+func resolveValue(documents []ParsedDocument, source ValueSource, dir string) (string, error) {
+	switch {
+	case len(source.File) > 0:
+		return fileValue(documents, source.File, source.Regex)
+	case len(source.GoConst) > 0:
+		return goConstValue(documents, source.GoConst)
+	case len(source.GoMod) > 0:
+		return goModValue(documents, source.GoMod, dir)
+	case len(source.GitTag) > 0:
+		return gitTagValue(dir, source.GitTag)
+	}
+
+	return "", fmt.Errorf("no value source configured")
+}
+
+// valueKey identifies a resolved value, values from go.mod files and git tags depend on the directory of the document
+func valueKey(name string, source ValueSource, dir string) string {
+	if len(source.GoMod) > 0 || len(source.GitTag) > 0 {
+		return name + "@" + dir
+	}
+
+	return name
+}
+
+// inlineValueLines reports for every line of document whether inline value markers in it are replaced. Lines inside of
+// fenced code blocks, e.g. examples of the markers themselves, and insert regions, whose content is generated, are
+// skipped.
+func inlineValueLines(document ParsedDocument) []bool {
+	result := make([]bool, len(document.Lines))
+
+	depth := 0
+	fence := ""
+	for index, line := range document.Lines {
+		if marker := line.Snippet; marker != nil && marker.IsInsert() {
+			if marker.IsStart {
+				depth++
+			} else if marker.IsEnd && depth > 0 {
+				depth--
+			}
+			continue
+		}
+
+		if depth > 0 {
+			continue
+		}
+
+		if len(fence) > 0 {
+			if strings.HasPrefix(strings.TrimSpace(line.line), fence) && len(strings.Trim(strings.TrimSpace(line.line), fence[:1])) == 0 {
+				fence = ""
+			}
+			continue
+		}
+
+		if match := markdownFenceExpression.FindStringSubmatch(line.line); match != nil {
+			fence = match[1]
+			continue
+		}
+
+		result[index] = true
+	}
+
+	return result
+}
+
+// replaceInlineValues replaces the values of all inline value markers in the lines of a document, values are
+// resolved once and stored in resolved
+func replaceInlineValues(documents []ParsedDocument, document ParsedDocument, values map[string]ValueSource, resolved map[string]string) error {
+	replaced := inlineValueLines(document)
+	dir := filepath.Dir(document.File)
+
+	for index, line := range document.Lines {
+		if !replaced[index] || !strings.Contains(line.line, "v:") {
+			continue
+		}
+
+		var err error
+		document.Lines[index].line = inlineValueExpression.ReplaceAllStringFunc(line.line, func(match string) string {
+			groups := inlineValueExpression.FindStringSubmatch(match)
+			name := groups[2]
+
+			source, ok := values[name]
+			if !ok {
+				err = fmt.Errorf("unknown value '%s' in '%s:%d'", name, document.File, line.number+1)
+				return match
+			}
+
+			key := valueKey(name, source, dir)
+			value, ok := resolved[key]
+			if !ok {
+				value, err = resolveValue(documents, source, dir)
+				if err != nil {
+					err = fmt.Errorf("could not resolve value '%s' in '%s:%d': %s", name, document.File, line.number+1, err)
+					return match
+				}
+				resolved[key] = value
+			}
+
+			return groups[1] + value + groups[3]
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateInlineValues checks that all inline value markers reference a configured value that can be resolved, values
+// from git tags are only resolved when replacing
+func validateInlineValues(documents []ParsedDocument, values map[string]ValueSource) []error {
+	var errors []error
+	resolveErrors := map[string]error{}
+
+	for _, document := range documents {
+		validated := inlineValueLines(document)
+		dir := filepath.Dir(document.File)
+
+		for index, line := range document.Lines {
+			if !validated[index] {
+				continue
+			}
+
+			for _, groups := range inlineValueExpression.FindAllStringSubmatch(line.line, -1) {
+				name := groups[2]
+
+				source, ok := values[name]
+				if !ok {
+					errors = append(errors, fmt.Errorf("unknown value '%s' in '%s:%d'", name, document.File, line.number+1))
+					continue
+				}
+
+				if len(source.GitTag) > 0 {
+					continue
+				}
+
+				key := valueKey(name, source, dir)
+				err, resolved := resolveErrors[key]
+				if !resolved {
+					_, err = resolveValue(documents, source, dir)
+					resolveErrors[key] = err
+				}
+
+				if err != nil {
+					errors = append(errors, fmt.Errorf("could not resolve value '%s' in '%s:%d': %s", name, document.File, line.number+1, err))
+				}
+			}
+		}
+	}
+
+	return errors
+}
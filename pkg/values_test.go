@@ -0,0 +1,165 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+var valuesGoMod = `module github.com/example/project
+
+go 1.21
+
+require github.com/alecthomas/assert/v2 v2.3.0
+
+require (
+	github.com/urfave/cli/v2 v2.25.7 // indirect
+)
+`
+
+func TestReplaceInlineValues(t *testing.T) {
+	documents := parseDocumentsTest(t,
+		Document{File: "README.md", Content: "Install <!--v:version-->0.0.0<!--/v--> with Go <!-- v:go -->1.19<!-- /v --> and cli <!--v:cli--><!--/v-->"},
+		Document{File: "go.mod", Content: valuesGoMod},
+		Document{File: "version/version.go", Content: "package version\n\nconst (\n\tName    = \"snex\"\n\tVersion = \"1.2.3\"\n)\n"},
+	)
+
+	config := Config{Values: map[string]ValueSource{
+		"version": {GoConst: "version/version.go#Version"},
+		"go":      {GoMod: "go"},
+		"cli":     {GoMod: "github.com/urfave/cli/v2"},
+	}}
+
+	replaced, err := ReplaceSnippetsWithConfig(documents, "", config)
+	assert.NoError(t, err)
+	assert.Equal(t, "Install <!--v:version-->1.2.3<!--/v--> with Go <!-- v:go -->1.21<!-- /v --> and cli <!--v:cli-->v2.25.7<!--/v-->", replaced[0].Content)
+}
+
+func TestReplaceInlineValuesUnknown(t *testing.T) {
+	documents := parseDocumentsTest(t, Document{File: "README.md", Content: "first\n<!--v:version-->0.0.0<!--/v-->"})
+
+	_, err := ReplaceSnippetsWithConfig(documents, "", Config{})
+	assert.EqualError(t, err, "unknown value 'version' in 'README.md:2'")
+}
+
+func TestValidateInlineValues(t *testing.T) {
+	documents := parseDocumentsTest(t,
+		Document{File: "README.md", Content: "<!--v:version-->1.0<!--/v--> <!--v:chart-->1.0<!--/v-->\n<!--v:tag-->v1<!--/v--> <!--v:unknown--><!--/v-->"},
+		Document{File: "Chart.yaml", Content: "name: snex\n"},
+	)
+
+	config := Config{Values: map[string]ValueSource{
+		"version": {File: "Chart.yaml", Regex: "^name: (.+)$"},
+		"chart":   {File: "Chart.yaml", Regex: "^version: (.+)$"},
+		"tag":     {GitTag: "v*"},
+	}}
+
+	errors := ValidateDocumentsWithConfig(documents, config)
+	assert.Equal(t, 2, len(errors))
+	assert.EqualError(t, errors[0], "could not resolve value 'chart' in 'README.md:1': regex '^version: (.+)$' does not match 'Chart.yaml'")
+	assert.EqualError(t, errors[1], "unknown value 'unknown' in 'README.md:2'")
+}
+
+func TestFileValue(t *testing.T) {
+	documents := parseDocumentsTest(t, Document{File: "Chart.yaml", Content: "name: snex\nversion: 0.4.2\n"})
+
+	value, err := fileValue(documents, "Chart.yaml", `^version: (.+)$`)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.4.2", value)
+
+	value, err = fileValue(documents, "Chart.yaml", `\d+\.\d+\.\d+`)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.4.2", value)
+
+	_, err = fileValue(documents, "Chart.yaml", `^appVersion: (.+)$`)
+	assert.Error(t, err)
+}
+
+func TestGoModValue(t *testing.T) {
+	documents := parseDocumentsTest(t, Document{File: "go.mod", Content: valuesGoMod})
+
+	value, err := goModValue(documents, "module", ".")
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/example/project", value)
+
+	value, err = goModValue(documents, "github.com/alecthomas/assert/v2", ".")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2.3.0", value)
+
+	value, err = goModValue(documents, "go", ".")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.21", value)
+
+	_, err = goModValue(documents, "github.com/unknown/module", ".")
+	assert.Error(t, err)
+}
+
+func TestGoModValueNearest(t *testing.T) {
+	documents := parseDocumentsTest(t,
+		Document{File: "go.mod", Content: valuesGoMod},
+		Document{File: "tools/go.mod", Content: "module github.com/example/tools\n"},
+		Document{File: "other/go.mod", Content: "module github.com/example/other\n"},
+	)
+
+	value, err := goModValue(documents, "module", "tools/docs")
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/example/tools", value)
+
+	value, err = goModValue(documents, "module", "docs")
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com/example/project", value)
+
+	_, err = goModValue(documents[1:], "module", "docs")
+	assert.EqualError(t, err, "no 'go.mod' found in 'docs' or its parent directories")
+}
+
+func TestReplaceInlineValuesSkipsFencesAndInserts(t *testing.T) {
+	documents := parseDocumentsTest(t,
+		Document{File: "README.md", Content: "<!--v:version-->0.0.0<!--/v-->\n```markdown\n<!--v:example-->1.2.3<!--/v-->\n```\ninsertFile[docs/values.md]\n<!--v:example-->stale<!--/v-->\n/insertFile"},
+		Document{File: "docs/values.md", Content: "Version <!--v:version-->0.0.0<!--/v-->"},
+		Document{File: "version/version.go", Content: "package version\n\nconst Version = \"1.2.3\"\n"},
+	)
+
+	config := Config{Values: map[string]ValueSource{"version": {GoConst: "version/version.go#Version"}}}
+	assert.Equal(t, 0, len(ValidateDocumentsWithConfig(documents, config)))
+
+	replaced, err := ReplaceSnippetsWithConfig(documents, "{{.Content}}", config)
+	assert.NoError(t, err)
+	assert.Equal(t, "<!--v:version-->1.2.3<!--/v-->\n```markdown\n<!--v:example-->1.2.3<!--/v-->\n```\ninsertFile[docs/values.md]\nVersion <!--v:version-->1.2.3<!--/v-->\n/insertFile", replaced[0].Content)
+	assert.Equal(t, "Version <!--v:version-->1.2.3<!--/v-->", replaced[1].Content)
+}
+
+func TestGitTagValue(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0644))
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "README.md"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+		{"tag", "v1.0.0"},
+		{"tag", "latest"},
+	} {
+		command := exec.Command("git", args...)
+		command.Dir = dir
+		assert.NoError(t, command.Run())
+	}
+
+	value, err := gitTagValue(dir, "v*")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0", value)
+}
+
+func TestValueSourceValidate(t *testing.T) {
+	assert.NoError(t, ValueSource{GitTag: "v*"}.Validate())
+	assert.Error(t, ValueSource{}.Validate())
+	assert.Error(t, ValueSource{GitTag: "v*", GoMod: "go"}.Validate())
+	assert.Error(t, ValueSource{File: "Chart.yaml"}.Validate())
+	assert.Error(t, ValueSource{File: "Chart.yaml", Regex: "("}.Validate())
+}
@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// VarEnvPrefix is the prefix of environment variables that define variables, e.g. 'SNEX_VAR_port=8080' defines 'port'
+const VarEnvPrefix = "SNEX_VAR_"
+
+var varNameExpression = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// varPlaceholderExpression matches variable placeholders like '${{ snex.port }}' inside of inserted content
+var varPlaceholderExpression = regexp.MustCompile(`\$\{\{\s*snex\.([a-zA-Z0-9_]+)\s*\}\}`)
+
+func validateVarName(name string) error {
+	if !varNameExpression.MatchString(name) {
+		return fmt.Errorf("invalid variable name '%s', names may only contain letters, digits and '_'", name)
+	}
+
+	return nil
+}
+
+// ParseVar parses a variable definition like 'port=8080'
+func ParseVar(definition string) (string, string, error) {
+	name, value, found := strings.Cut(definition, "=")
+	if !found {
+		return "", "", fmt.Errorf("invalid variable '%s', expected format is 'name=value'", definition)
+	}
+
+	if err := validateVarName(name); err != nil {
+		return "", "", err
+	}
+
+	return name, value, nil
+}
+
+// EnvVars returns the variables defined by environment variables with the prefix VarEnvPrefix, environ has the
+// format of os.Environ
+func EnvVars(environ []string) map[string]string {
+	vars := map[string]string{}
+
+	for _, env := range environ {
+		if !strings.HasPrefix(env, VarEnvPrefix) {
+			continue
+		}
+
+		name, value, err := ParseVar(strings.TrimPrefix(env, VarEnvPrefix))
+		if err != nil {
+			continue
+		}
+		vars[name] = value
+	}
+
+	return vars
+}
+
+// replaceVars replaces all variable placeholders like '${{ snex.port }}' in lines with the value of the variable
+func replaceVars(lines []string, vars map[string]string) ([]string, error) {
+	var result []string
+
+	for _, line := range lines {
+		var err error
+		line = varPlaceholderExpression.ReplaceAllStringFunc(line, func(match string) string {
+			name := varPlaceholderExpression.FindStringSubmatch(match)[1]
+
+			value, ok := vars[name]
+			if !ok {
+				err = fmt.Errorf("unknown variable '%s'", name)
+				return match
+			}
+
+			return value
+		})
+
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, line)
+	}
+
+	return result, nil
+}
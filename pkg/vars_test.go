@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"github.com/alecthomas/assert/v2"
+	"testing"
+)
+
+func TestParseVar(t *testing.T) {
+	name, value, err := ParseVar("url=http://localhost:8080/?a=b")
+	assert.NoError(t, err)
+	assert.Equal(t, "url", name)
+	assert.Equal(t, "http://localhost:8080/?a=b", value)
+
+	_, _, err = ParseVar("port")
+	assert.Error(t, err)
+
+	_, _, err = ParseVar("product-name=snex")
+	assert.Error(t, err)
+}
+
+func TestEnvVars(t *testing.T) {
+	vars := EnvVars([]string{"PATH=/usr/bin", "SNEX_VAR_port=8080", "SNEX_VAR_product_name=Snex Enterprise"})
+	assert.Equal(t, map[string]string{"port": "8080", "product_name": "Snex Enterprise"}, vars)
+}
+
+func TestReplaceVars(t *testing.T) {
+	lines, err := replaceVars([]string{"listen ${{ snex.port }}", "name ${{snex.name}}, ci ${{ secrets.TOKEN }}"}, map[string]string{"port": "8080", "name": "snex"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"listen 8080", "name snex, ci ${{ secrets.TOKEN }}"}, lines)
+
+	_, err = replaceVars([]string{"${{ snex.unknown }}"}, map[string]string{})
+	assert.EqualError(t, err, "unknown variable 'unknown'")
+}
+
+func TestReplaceSnippetsVars(t *testing.T) {
+	documents := parseDocumentsTest(t,
+		Document{File: "server.go", Content: "// snippet[listen]\nhttp.ListenAndServe(\":${{ snex.port }}\", nil)\n// /snippet"},
+		Document{File: "README.md", Content: "<!-- insertSnippet[listen] -->\n<!-- /insertSnippet -->"},
+	)
+
+	config := Config{Vars: map[string]string{"port": "9090", "product": "Snex"}}
+	replaced, err := ReplaceSnippetsWithConfig(documents, "{{.Vars.product}}: {{.Content}}", config)
+	assert.NoError(t, err)
+	assert.Equal(t, "<!-- insertSnippet[listen] -->\nSnex: http.ListenAndServe(\":9090\", nil)\n<!-- /insertSnippet -->", replaced[1].Content)
+
+	_, err = ReplaceSnippetsWithConfig(documents, "{{.Vars.edition}}: {{.Content}}", config)
+	assert.EqualError(t, err, `template: snippet:1:7: executing "snippet" at <.Vars.edition>: map has no entry for key "edition"`)
+
+	_, err = ReplaceSnippetsWithConfig(documents, "", Config{})
+	assert.EqualError(t, err, "could not insert 'listen' into 'README.md:1': unknown variable 'port'")
+}